@@ -0,0 +1,87 @@
+package sn
+
+import (
+	"sync"
+)
+
+// EventKind represents a kind of change to a game published to subscribers of the game
+type EventKind string
+
+const (
+	// CommitEvent indicates a revision of the game was committed or saved
+	CommitEvent EventKind = "commit"
+
+	// CacheEvent indicates a user cached an uncommitted revision of the game
+	CacheEvent EventKind = "cache"
+
+	// StackEvent indicates a user reset, undid, or redid cached revisions of the game
+	StackEvent EventKind = "stack"
+
+	// MessageEvent indicates a message was added to the message log of the game
+	MessageEvent EventKind = "message"
+
+	// MessageUpdatedEvent indicates a message of the message log of the game was edited, deleted, or reacted to
+	MessageUpdatedEvent EventKind = "message-updated"
+)
+
+// gameEvent provides a change to a game.
+// UID provides the user whose cached revisions changed for CacheEvent and StackEvent events.
+// MessageID and Message provide the added or updated message for MessageEvent and MessageUpdatedEvent events.
+type gameEvent struct {
+	Kind      EventKind
+	GID       string
+	UID       UID
+	MessageID string
+	Message   *Message
+}
+
+// subscriberBuffer provides the number of events buffered for each subscriber.
+// Events published to a subscriber having a full buffer are dropped. As subscribers reload
+// the current state of a game upon receiving an event, only notification of the latest change matters.
+const subscriberBuffer = 16
+
+// broker provides in-process publish/subscribe of game events.
+// Events are published only after the associated change is stored.
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan gameEvent]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]map[chan gameEvent]struct{})}
+}
+
+// subscribe returns a channel receiving events of the game with id gid, and a func that ends the subscription
+func (b *broker) subscribe(gid string) (<-chan gameEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan gameEvent, subscriberBuffer)
+	if b.subs[gid] == nil {
+		b.subs[gid] = make(map[chan gameEvent]struct{})
+	}
+	b.subs[gid][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subs[gid], ch)
+		if len(b.subs[gid]) == 0 {
+			delete(b.subs, gid)
+		}
+	}
+}
+
+// publish sends the event to all subscribers of the game of the event without blocking
+func (b *broker) publish(e gameEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[e.GID] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package sn
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultInvitationLimit = 25
+	maxInvitationLimit     = 100
+)
+
+// invitationEntry provides an invitation along with the ratings and stats of its users
+type invitationEntry struct {
+	Invitation invitation
+	Details    []detail
+}
+
+// invitationsHandler returns a page of open invitations, newest first, along with the recruiting invitations
+// joined by the current user and the running games in which it is the turn of the current user.
+// Query parameters:
+//   - type: limits invitations to a game type
+//   - players: limits invitations to a player count
+//   - private: true or false limits invitations to private or public invitations
+//   - creator: limits invitations to those created by a user
+//   - elo: limits invitations to those whose users all have a rating within elo of the rating of the current user
+//   - offset, limit: provide pagination
+//
+// Expired and full invitations, and those outside the elo range, are removed from each page after pagination,
+// so pages may hold fewer than limit invitations.
+// Requires composite indices on the Status, Type, NumPlayers, Private, CreatorID, and CreatedAt fields of the Invitation collection.
+func (cl *GameClient[GT, G]) invitationsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		q, elo, err := getInvitationQuery(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		invs, err := cl.listInvitations(ctx, q)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		now := time.Now()
+		entries := make([]invitationEntry, 0, len(invs))
+		for _, inv := range invs {
+			if inv.expired(now) || (inv.openSeats() <= 0 && !inv.invited(cu.ID)) {
+				continue
+			}
+
+			details, err := cl.getDetails(ctx, &inv, cu)
+			if err != nil {
+				JErr(ctx, err)
+				return
+			}
+
+			if elo > 0 && !withinElo(details, cu.ID, elo) {
+				continue
+			}
+			entries = append(entries, invitationEntry{Invitation: inv, Details: details})
+		}
+
+		next := -1
+		if len(invs) == q.Limit {
+			next = q.Offset + q.Limit
+		}
+
+		mine, err := cl.listInvitations(ctx, InvitationQuery{Status: Recruiting, UserID: cu.ID})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		yourTurn, err := cl.listIndexes(ctx, IndexQuery{Status: Running, CurrentUserID: cu.ID})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Invitations": entries,
+			"Offset":      q.Offset,
+			"Next":        next,
+			"Mine":        mine,
+			"YourTurn":    yourTurn,
+		})
+	}
+}
+
+// getInvitationQuery returns a query for recruiting invitations per the query parameters, and the elo range, if any
+func getInvitationQuery(ctx *gin.Context) (InvitationQuery, int, error) {
+	q := InvitationQuery{Status: Recruiting, Type: Type(ctx.Query("type"))}
+
+	var err error
+	if q.NumPlayers, err = getIntQuery(ctx, "players", 0); err != nil {
+		return InvitationQuery{}, 0, err
+	}
+
+	if s := ctx.Query("private"); s != "" {
+		private, err := strconv.ParseBool(s)
+		if err != nil {
+			return InvitationQuery{}, 0, fmt.Errorf("invalid private %q: %w", s, ErrValidation)
+		}
+		q.Private = &private
+	}
+
+	creator, err := getIntQuery(ctx, "creator", 0)
+	if err != nil {
+		return InvitationQuery{}, 0, err
+	}
+	q.CreatorID = UID(creator)
+
+	elo, err := getIntQuery(ctx, "elo", 0)
+	if err != nil {
+		return InvitationQuery{}, 0, err
+	}
+
+	if q.Offset, err = getIntQuery(ctx, "offset", 0); err != nil {
+		return InvitationQuery{}, 0, err
+	}
+
+	if q.Limit, err = getIntQuery(ctx, "limit", defaultInvitationLimit); err != nil {
+		return InvitationQuery{}, 0, err
+	}
+
+	if q.Offset < 0 || q.Limit < 1 || q.Limit > maxInvitationLimit || elo < 0 {
+		return InvitationQuery{}, 0, fmt.Errorf("offset and elo must be non-negative and limit between 1 and %d: %w",
+			maxInvitationLimit, ErrValidation)
+	}
+	return q, elo, nil
+}
+
+// listInvitations returns the invitations matching the query, less email addresses
+func (cl *GameClient[GT, G]) listInvitations(ctx *gin.Context, q InvitationQuery) ([]invitation, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	docs, err := cl.store.ListInvitations(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	invs := make([]invitation, len(docs))
+	for i, doc := range docs {
+		var inv invitation
+		if err := doc.DataTo(&inv); err != nil {
+			return nil, err
+		}
+		inv.setID(doc.ID())
+		invs[i] = inv.public()
+	}
+	return invs, nil
+}
+
+// withinElo returns true if the rating of each user of the details is within elo of the rating of the user having the uid
+func withinElo(details []detail, uid UID, elo int) bool {
+	i := slices.IndexFunc(details, func(d detail) bool { return d.ID == uid })
+	if i < 0 {
+		return false
+	}
+
+	rating := details[i].ELO
+	return !slices.ContainsFunc(details, func(d detail) bool { return d.ELO < rating-elo || d.ELO > rating+elo })
+}
@@ -4,11 +4,9 @@ package sn
 import (
 	"context"
 	"errors"
-	"fmt"
 	"slices"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/patrickmn/go-cache"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -18,6 +16,10 @@ import (
 type Client struct {
 	Cache  *cache.Cache
 	Router *gin.Engine
+	broker *broker
+	// outbox prompts the outbox worker to deliver pending notifications
+	outbox     chan struct{}
+	stopOutbox context.CancelFunc
 	options
 }
 
@@ -34,6 +36,11 @@ func defaultClient() *Client {
 	cl.secretsDSURL = getSecretsDSURL()
 	cl.prefix = getPrefix()
 	cl.home = getHome()
+	cl.rater = EloRater{}
+	cl.mailer = defaultMailer()
+	cl.templates = newTemplateRegistry()
+	cl.broker = newBroker()
+	cl.outbox = make(chan struct{}, 1)
 	return cl
 }
 
@@ -61,6 +68,7 @@ func (cl *Client) initCache() *Client {
 
 func (cl *Client) initRouter() *Client {
 	cl.Router = gin.Default()
+	cl.Router.Use(cl.requestContext())
 	return cl
 }
 
@@ -80,70 +88,60 @@ func (cl *Client) initEnvironment(ctx context.Context) *Client {
 
 // Close closes client
 func (cl *Client) Close() error {
+	if cl.stopOutbox != nil {
+		cl.stopOutbox()
+	}
 	return nil
 }
 
-func (cl *GameClient[GT, G]) revCollectionRef(gid string) *firestore.CollectionRef {
-	return cl.gameDocRef(gid).Collection("Rev")
-}
-
-func (cl *GameClient[GT, G]) revDocRef(gid string, rev Rev) *firestore.DocumentRef {
-	return cl.revCollectionRef(gid).Doc(rev.toString())
-}
-
-func (cl *GameClient[GT, G]) gameDocRef(gid string) *firestore.DocumentRef {
-	return cl.gameCollectionRef().Doc(gid)
-}
-
-func (cl *GameClient[GT, G]) gameCollectionRef() *firestore.CollectionRef {
-	return cl.FS.Collection("Game")
-}
-
-func (cl *GameClient[GT, G]) cachedDocRef(id string, uid UID, rev Rev) *firestore.DocumentRef {
-	return cl.cachedCollectionRef(id, uid).Doc(rev.toString())
-}
-
-func (cl *GameClient[GT, G]) cachedCollectionRef(gid string, uid UID) *firestore.CollectionRef {
-	return cl.gameDocRef(gid).Collection("CacheFor").Doc(uid.toString()).Collection("Rev")
-}
-
-func (cl *GameClient[GT, G]) messageDocRef(gid string, mid string) *firestore.DocumentRef {
-	return cl.messagesCollectionRef(gid).Doc(mid)
-}
-
-func (cl *GameClient[GT, G]) messagesCollectionRef(gid string) *firestore.CollectionRef {
-	return cl.gameCollectionRef().Doc(gid).Collection("Messages")
-}
-
-func (cl *GameClient[GT, G]) indexDocRef(id string) *firestore.DocumentRef {
-	return cl.FS.Collection("Index").Doc(id)
-}
-
-func (cl *GameClient[GT, G]) viewDocRef(gid string, uid UID) *firestore.DocumentRef {
-	return cl.gameDocRef(gid).Collection("For").Doc(uid.toString())
-}
-
 // Close closes the game service client
 func (cl *GameClient[GT, G]) Close() error {
-	cl.FS.Close()
+	if cl.FS != nil {
+		cl.FS.Close()
+	}
 	return cl.Client.Close()
 }
 
-func (cl *GameClient[GT, G]) commit(ctx *gin.Context, g G, uid UID) error {
+// commit commits the game and adds the notifications to the outbox in the same transaction.
+// Returns a ConflictError if the game changed since the version, if any, was read.
+func (cl *GameClient[GT, G]) commit(ctx *gin.Context, g G, uid UID, v *version, ns ...notification) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	g.header().UpdatedAt = timestamppb.Now()
 
-	return cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
-		return cl.txCommit(ctx, tx, g, uid)
-	})
+	stack := *g.stack()
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		if err := cl.txCommit(ctx, tx, g, uid, v, stack); err != nil {
+			return err
+		}
+		return cl.txNotify(tx, ns...)
+	}); err != nil {
+		return err
+	}
+
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id(), UID: uid})
+	if len(ns) > 0 {
+		cl.kickOutbox()
+	}
+	return nil
 }
 
-func (cl *GameClient[GT, G]) txCommit(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+// txCommit commits the game, whose stack before the commit is provided by stack.
+// As stores may retry transactions, the stack of the game is restored from stack before committing,
+// thereby ensuring each attempt commits the stack only once.
+func (cl *GameClient[GT, G]) txCommit(ctx context.Context, tx Tx, g G, uid UID, v *version, stack Stack) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
+	g.setStack(&stack)
+
+	if v != nil {
+		if err := cl.txCheckVersion(ctx, tx, v); err != nil {
+			return err
+		}
+	}
+
 	g.stack().commit()
 
 	index, err := cl.txGetIndex(ctx, tx, g.id())
@@ -152,8 +150,9 @@ func (cl *GameClient[GT, G]) txCommit(ctx context.Context, tx *firestore.Transac
 	}
 
 	if index.Rev+1 != g.stack().Committed {
-		return fmt.Errorf("unexpected game change")
+		return &ConflictError{GID: g.id(), Field: "rev", Expected: g.stack().Committed - 1, Actual: index.Rev}
 	}
+	g.header().mergeTurnReminders(&index.Header)
 
 	if err := cl.txDeleteCachedRevs(ctx, tx, g, uid); err != nil {
 		return err
@@ -162,16 +161,28 @@ func (cl *GameClient[GT, G]) txCommit(ctx context.Context, tx *firestore.Transac
 	return cl.txSave(ctx, tx, g, uid)
 }
 
-func (cl *GameClient[GT, G]) save(ctx *gin.Context, g G, uid UID) error {
+// save saves the game and adds the notifications to the outbox in the same transaction
+func (cl *GameClient[GT, G]) save(ctx *gin.Context, g G, uid UID, ns ...notification) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
-		return cl.txSave(ctx, tx, g, uid)
-	})
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		if err := cl.txSave(ctx, tx, g, uid); err != nil {
+			return err
+		}
+		return cl.txNotify(tx, ns...)
+	}); err != nil {
+		return err
+	}
+
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id(), UID: uid})
+	if len(ns) > 0 {
+		cl.kickOutbox()
+	}
+	return nil
 }
 
-func (cl *GameClient[GT, G]) txSave(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+func (cl *GameClient[GT, G]) txSave(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
@@ -190,44 +201,44 @@ func (cl *GameClient[GT, G]) txSave(ctx context.Context, tx *firestore.Transacti
 	return cl.txUpdateIndex(ctx, tx, g)
 }
 
-func (cl *GameClient[GT, G]) txUpdateIndex(ctx context.Context, tx *firestore.Transaction, g G) error {
+func (cl *GameClient[GT, G]) txUpdateIndex(ctx context.Context, tx Tx, g G) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return tx.Set(cl.indexDocRef(g.id()), g.toIndex())
+	return tx.SetIndex(g.id(), g.toIndex())
 }
 
-func (cl *GameClient[GT, G]) txUpdateRev(ctx context.Context, tx *firestore.Transaction, g G) error {
+func (cl *GameClient[GT, G]) txUpdateRev(ctx context.Context, tx Tx, g G) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return tx.Set(cl.revDocRef(g.id(), g.stack().Current), g)
+	return tx.SetRev(g.id(), g.stack().Current, g)
 }
 
 func (cl *GameClient[GT, G]) updateViews(ctx *gin.Context, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+	return cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
 		return cl.txUpdateViews(ctx, tx, g, uid)
 	})
 }
 
-func (cl *GameClient[GT, G]) txUpdateViews(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+func (cl *GameClient[GT, G]) txUpdateViews(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	// By implementing Views interface, game may provide a customized view for each user.
 	// Primarily used to ensure hidden game information not leaked to users via json objects
 	// sent to browsers.
-	uids, views := g.Views()
+	uids, views := cl.views(g)
 	if !slices.Contains(uids, uid) {
 		uids = append(uids, uid)
-		views = append(views, g.ViewFor(uid))
+		views = append(views, cl.viewFor(g, uid))
 	}
 
 	for i, v := range views {
-		if err := tx.Set(cl.viewDocRef(g.id(), uids[i]), v); err != nil {
+		if err := tx.SetView(g.id(), uids[i], v); err != nil {
 			return err
 		}
 	}
@@ -235,7 +246,7 @@ func (cl *GameClient[GT, G]) txUpdateViews(ctx context.Context, tx *firestore.Tr
 }
 
 // attempts to remove revs passed current save
-func (cl *GameClient[GT, G]) txDeleteCachedRevs(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+func (cl *GameClient[GT, G]) txDeleteCachedRevs(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
@@ -251,37 +262,65 @@ func (cl *GameClient[GT, G]) txDeleteCachedRevs(ctx context.Context, tx *firesto
 	return nil
 }
 
-func (cl *GameClient[GT, G]) txDeleteCachedRev(ctx context.Context, tx *firestore.Transaction, g G, uid UID, rev Rev) error {
+func (cl *GameClient[GT, G]) txDeleteCachedRev(ctx context.Context, tx Tx, g G, uid UID, rev Rev) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return tx.Delete(cl.cachedDocRef(g.id(), uid, rev))
+	return tx.DeleteCached(g.id(), uid, rev)
 }
 
 // By implementing Views interface, game may provide a customized view for each user.
 // Primarily used to ensure hidden game information not leaked to users via json objects
 // sent to browsers.
-func (cl *GameClient[GT, G]) txViews(ctx context.Context, tx *firestore.Transaction, g G) error {
+func (cl *GameClient[GT, G]) txViews(ctx context.Context, tx Tx, g G) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	uids, views := g.Views()
+	uids, views := cl.views(g)
 	for i, v := range views {
-		if err := tx.Set(cl.viewDocRef(g.id(), uids[i]), v); err != nil {
+		if err := tx.SetView(g.id(), uids[i], v); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (cl *GameClient[GT, G]) txSaveStack(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+// views returns the views provided by the Viewer interface of the game, with the seed of the game removed
+func (cl *GameClient[GT, G]) views(g G) ([]UID, []*GT) {
+	uids, views := g.Views()
+	for i := range views {
+		views[i] = hideSeed(g, views[i])
+	}
+	return uids, views
+}
+
+// viewFor returns the view for user provided by the Viewer interface of the game, with the seed of the game removed
+func (cl *GameClient[GT, G]) viewFor(g G, uid UID) *GT {
+	return hideSeed(g, g.ViewFor(uid))
+}
+
+// hideSeed removes the seed of game g from view v.
+// If v is not a copy of g, v is copied before removing the seed, thereby leaving the seed of g unchanged.
+func hideSeed[GT any, G Gamer[GT]](g G, v *GT) *GT {
+	if v == nil {
+		return nil
+	}
+
+	if any(v) == any(g) {
+		v = DeepCopy(v)
+	}
+	G(v).header().Seed = 0
+	return v
+}
+
+func (cl *GameClient[GT, G]) txSaveStack(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return tx.Set(cl.stackDocRef(g.id(), uid), g.stack())
+	return tx.SetStack(g.id(), uid, g.stack())
 }
 
-func (cl *GameClient[GT, G]) txSaveStacks(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+func (cl *GameClient[GT, G]) txSaveStacks(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
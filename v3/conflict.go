@@ -0,0 +1,123 @@
+package sn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxConflictRetries provides the number of times an action is retried upon conflicting changes to the game
+const maxConflictRetries = 3
+
+// ConflictError indicates the game changed between reading the game and saving the changes of an action,
+// for example, when two current players finish their turns at the same moment.
+type ConflictError struct {
+	GID string
+	// Field provides the part of the game that changed, i.e., rev or stack
+	Field    string
+	Expected any
+	Actual   any
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("game %s changed while performing action: expected %s %v, found %v",
+		e.GID, e.Field, e.Expected, e.Actual)
+}
+
+// Unwrap permits errors.Is(err, ErrConflict)
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// ConflictPolicy provides the handling of ConflictErrors by CachedHandler, CommitHandler, and FinishTurnHandler
+type ConflictPolicy int
+
+const (
+	// RetryConflicts performs the action again against the changed game, before rejecting the action
+	// should conflicts persist. Actions are expected to validate against, and only change, the provided game.
+	RetryConflicts ConflictPolicy = iota
+
+	// RejectConflicts rejects the action, leaving the user to retry
+	RejectConflicts
+)
+
+// WithConflictPolicy sets the default conflict policy of game handlers.
+// Defaults to RetryConflicts.
+func WithConflictPolicy(p ConflictPolicy) Option {
+	return func(cl *Client) *Client {
+		cl.conflictPolicy = p
+		return cl
+	}
+}
+
+// version provides the committed rev of a game, and the stack of a user, read before performing an action
+type version struct {
+	GID   string
+	UID   UID
+	Rev   Rev
+	Stack Stack
+}
+
+// versionOf returns the version of the game for the user
+func versionOf[GT any, G Gamer[GT]](g G, uid UID) *version {
+	return &version{GID: g.id(), UID: uid, Rev: g.stack().Committed, Stack: *g.stack()}
+}
+
+// txCheckVersion returns a ConflictError if the committed rev of the game or the stack of the user
+// no longer match the version. As with all reads of a transaction, it must precede any writes.
+func (cl *GameClient[GT, G]) txCheckVersion(ctx context.Context, tx Tx, v *version) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	index, err := cl.txGetIndex(ctx, tx, v.GID)
+	if err != nil {
+		return err
+	}
+
+	if index.Rev != v.Rev {
+		return &ConflictError{GID: v.GID, Field: "rev", Expected: v.Rev, Actual: index.Rev}
+	}
+
+	var stack Stack
+	if err := tx.GetStack(v.GID, v.UID, &stack); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if stack != v.Stack {
+		return &ConflictError{GID: v.GID, Field: "stack", Expected: v.Stack, Actual: stack}
+	}
+	return nil
+}
+
+// conflictPolicyFor returns the policy provided to a handler, if any, otherwise the default policy of the client
+func (cl *GameClient[GT, G]) conflictPolicyFor(policy []ConflictPolicy) ConflictPolicy {
+	if len(policy) > 0 {
+		return policy[0]
+	}
+	return cl.conflictPolicy
+}
+
+// withRetry performs attempt, again performing attempt per the policy while attempt returns a ConflictError.
+// The request body is restored before each attempt, so actions may bind the body anew.
+func withRetry(ctx *gin.Context, policy ConflictPolicy, attempt func() error) error {
+	var body []byte
+	if ctx.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(ctx.Request.Body); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; ; i++ {
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		err := attempt()
+		if policy != RetryConflicts || i >= maxConflictRetries || !errors.Is(err, ErrConflict) {
+			return err
+		}
+		Warnf(ctx, "retrying action: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package sn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commitConcurrently commits a change to the game as if made by another request, bumping Count by 10
+func commitConcurrently(ctx *gin.Context, cl *testClient, gid string) error {
+	var stack Stack
+	if err := cl.store.GetStack(ctx, gid, 0, &stack); err != nil {
+		return err
+	}
+
+	g, err := cl.getRev(ctx, gid, stack.Current)
+	if err != nil {
+		return err
+	}
+	g.setStack(&stack)
+	v := versionOf(g, 0)
+
+	g.State.Count += 10
+	g.stack().update()
+	return cl.commit(ctx, g, 0, v)
+}
+
+func getTestIndex(t *testing.T, cl *testClient) index {
+	t.Helper()
+
+	var i index
+	if err := cl.store.GetIndex(context.Background(), testGID, &i); err != nil {
+		t.Fatalf("GetIndex: %v", err)
+	}
+	return i
+}
+
+func getTestRev(t *testing.T, cl *testClient, rev Rev) *testGame {
+	t.Helper()
+
+	g := new(testGame)
+	if err := cl.store.GetRev(context.Background(), testGID, rev, g); err != nil {
+		t.Fatalf("GetRev: %v", err)
+	}
+	return g
+}
+
+func TestCommitHandlerCommitsAction(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	cl.Router.PUT("/game/:id", cl.CommitHandler(func(g *testGame, _ *gin.Context, _ *User) (Result, error) {
+		g.State.Count++
+		return Result{Message: "counted"}, nil
+	}))
+
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var resp ActionResponse[testGame]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Game.State.Count != 1 || resp.Stack.Committed != 1 || resp.Message != "counted" {
+		t.Errorf("got Count %d, Committed %d, Message %q, want 1, 1, \"counted\"",
+			resp.Game.State.Count, resp.Stack.Committed, resp.Message)
+	}
+
+	if i := getTestIndex(t, cl); i.Rev != 1 {
+		t.Errorf("index Rev = %d, want 1", i.Rev)
+	}
+
+	if g := getTestRev(t, cl, 1); g.State.Count != 1 {
+		t.Errorf("rev 1 Count = %d, want 1", g.State.Count)
+	}
+}
+
+func TestCommitHandlerRetriesConflicts(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", cl.CommitHandler(func(g *testGame, ctx *gin.Context, _ *User) (Result, error) {
+		calls++
+		if calls == 1 {
+			if err := commitConcurrently(ctx, cl, g.id()); err != nil {
+				return Result{}, err
+			}
+		}
+		g.State.Count++
+		return Result{}, nil
+	}))
+
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	// the retried action applies to the concurrently committed game, and commits exactly once
+	if i := getTestIndex(t, cl); i.Rev != 2 {
+		t.Errorf("index Rev = %d, want 2", i.Rev)
+	}
+
+	if g := getTestRev(t, cl, 2); g.State.Count != 11 {
+		t.Errorf("rev 2 Count = %d, want 11", g.State.Count)
+	}
+}
+
+func TestCommitHandlerRejectsConflicts(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", cl.CommitHandler(func(g *testGame, ctx *gin.Context, _ *User) (Result, error) {
+		calls++
+		if err := commitConcurrently(ctx, cl, g.id()); err != nil {
+			return Result{}, err
+		}
+		g.State.Count++
+		return Result{}, nil
+	}, RejectConflicts))
+
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, "", ErrorFormatHeader, "structured")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body)
+	}
+
+	var resp struct{ Error ErrorBody }
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != ConflictCode {
+		t.Errorf("Code = %q, want %q", resp.Error.Code, ConflictCode)
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	// only the concurrent change is committed
+	if i := getTestIndex(t, cl); i.Rev != 1 {
+		t.Errorf("index Rev = %d, want 1", i.Rev)
+	}
+}
+
+func TestCommitHandlerRejectsPersistentConflicts(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", cl.CommitHandler(func(g *testGame, ctx *gin.Context, _ *User) (Result, error) {
+		calls++
+		if err := commitConcurrently(ctx, cl, g.id()); err != nil {
+			return Result{}, err
+		}
+		g.State.Count++
+		return Result{}, nil
+	}))
+
+	// compat errors are returned with status OK, and identified by the X-Error-Code header
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ConflictCode {
+		t.Fatalf("%s = %q, want %q: %s", ErrorCodeHeader, code, ConflictCode, w.Body)
+	}
+
+	if calls != maxConflictRetries+1 {
+		t.Errorf("calls = %d, want %d", calls, maxConflictRetries+1)
+	}
+}
+
+func TestTxCheckVersion(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	ctx := context.Background()
+	check := func(v *version) error {
+		return cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			return cl.txCheckVersion(ctx, tx, v)
+		})
+	}
+
+	if err := check(&version{GID: testGID, UID: 1}); err != nil {
+		t.Errorf("current version: %v", err)
+	}
+
+	for _, v := range []*version{
+		{GID: testGID, UID: 1, Rev: 1},
+		{GID: testGID, UID: 1, Stack: Stack{Current: 1, Updated: 1}},
+	} {
+		err := check(v)
+		var cerr *ConflictError
+		if !errors.As(err, &cerr) || !errors.Is(err, ErrConflict) {
+			t.Errorf("check(%+v) = %v, want ConflictError", v, err)
+		}
+	}
+}
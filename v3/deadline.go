@@ -0,0 +1,288 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TimeoutAction represents the action taken when current players exceed the turn limit of a game
+type TimeoutAction string
+
+const (
+	// NoTimeoutAction is the zero value and represents a missing timeout action
+	NoTimeoutAction TimeoutAction = ""
+
+	// AutoPass specifies that the game passes on behalf of players exceeding the turn limit.
+	// Games that do not implement the AutoPasser interface fall back to AutoDrop.
+	AutoPass TimeoutAction = "pass"
+
+	// AutoDrop specifies that players exceeding the turn limit are dropped and the game is abandoned.
+	AutoDrop TimeoutAction = "drop"
+)
+
+// maxTurnLimit provides the longest turn limit that may be set for a game
+const maxTurnLimit = 30 * 24 * time.Hour
+
+// reminderFractions provide the fractions of the turn limit after which escalating reminders are sent
+var reminderFractions = []float64{0.5, 0.75, 0.9}
+
+// AutoPasser provides an optional hook for games having an AutoPass timeout action.
+// AutoPass should pass on behalf of the players associated with the provided player ids
+// and return the player ids of the next current players.
+// Returning no player ids ends the game.
+type AutoPasser interface {
+	AutoPass(context.Context, []PID) ([]PID, error)
+}
+
+func (h *Header) setTurnLimit(hours int, action TimeoutAction) error {
+	limit := time.Duration(hours) * time.Hour
+	switch {
+	case limit < 0 || limit > maxTurnLimit:
+		return fmt.Errorf("turn limit must be between 0 and %d hours: %w", int(maxTurnLimit.Hours()), ErrValidation)
+	case limit == 0:
+		h.TurnLimit, h.TimeoutAction = 0, NoTimeoutAction
+		return nil
+	case action == NoTimeoutAction:
+		action = AutoDrop
+	case action != AutoPass && action != AutoDrop:
+		return fmt.Errorf("unknown timeout action %q: %w", action, ErrValidation)
+	}
+	h.TurnLimit, h.TimeoutAction = limit, action
+	return nil
+}
+
+// startTurnClock starts the turn limit clock for the current players
+func (h *Header) startTurnClock() {
+	if h.TurnLimit <= 0 {
+		h.stopTurnClock()
+		return
+	}
+
+	h.TurnDeadline = timestamppb.New(time.Now().Add(h.TurnLimit))
+	h.TurnReminders = 0
+	h.TurnCheckAt = h.nextTurnCheck()
+}
+
+// stopTurnClock stops the turn limit clock
+func (h *Header) stopTurnClock() {
+	h.TurnDeadline, h.TurnCheckAt, h.TurnReminders = nil, nil, 0
+}
+
+// mergeTurnReminders restores the reminder state of committed header h2 to h.
+// As reminders update only the rev and index, games cached before a reminder retain the prior reminder state.
+// The state is restored only if the turn clock was neither restarted nor stopped since h2 was committed.
+func (h *Header) mergeTurnReminders(h2 *Header) {
+	if h.TurnDeadline == nil || h2.TurnDeadline == nil || !h.TurnDeadline.AsTime().Equal(h2.TurnDeadline.AsTime()) {
+		return
+	}
+	h.TurnReminders, h.TurnCheckAt = h2.TurnReminders, h2.TurnCheckAt
+}
+
+// nextTurnCheck returns the time of the next reminder, or the turn deadline if all reminders have been sent
+func (h *Header) nextTurnCheck() *timestamppb.Timestamp {
+	if h.TurnDeadline == nil {
+		return nil
+	}
+
+	deadline := h.TurnDeadline.AsTime()
+	if h.TurnReminders >= len(reminderFractions) {
+		return timestamppb.New(deadline)
+	}
+
+	start := deadline.Add(-h.TurnLimit)
+	offset := time.Duration(float64(h.TurnLimit) * reminderFractions[h.TurnReminders])
+	return timestamppb.New(start.Add(offset))
+}
+
+// requireCron returns an error unless the request was issued by App Engine cron or by an admin.
+// App Engine removes the X-Appengine-Cron header from requests not issued by cron.
+func (cl *Client) requireCron(ctx *gin.Context) error {
+	if ctx.GetHeader("X-Appengine-Cron") == "true" {
+		return nil
+	}
+	_, err := cl.RequireAdmin(ctx)
+	return err
+}
+
+// deadlinesHandler sends reminders to, and takes timeout actions against, players of running games
+// having a due turn check. Intended to be periodically invoked by App Engine cron.
+// Requires a composite index on the Status and TurnCheckAt fields of the Index collection.
+func (cl *GameClient[GT, G]) deadlinesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if err := cl.requireCron(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		now := time.Now()
+		docs, err := cl.store.ListIndexes(ctx, IndexQuery{Status: Running, CheckBy: now})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var checked int
+		for _, doc := range docs {
+			switch err := cl.checkDeadline(ctx, doc.ID(), now); {
+			case errors.Is(err, ErrConflict):
+				// Game changed since read, so the deadline is checked anew by the next run
+				Debugf(ctx, "skipped deadline of %s: %v", doc.ID(), err)
+			case err != nil:
+				Warnf(ctx, "unable to check deadline of %s: %v", doc.ID(), err)
+			default:
+				checked++
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Checked": checked})
+	}
+}
+
+func (cl *GameClient[GT, G]) checkDeadline(ctx *gin.Context, gid string, now time.Time) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	stack, err := cl.getStack(ctx, gid, 0)
+	if err != nil {
+		return err
+	}
+
+	g, err := cl.getGameWithStack(ctx, gid, 0, stack)
+	if err != nil {
+		return err
+	}
+
+	h, v := g.header(), versionOf(g, 0)
+	switch {
+	case h.Status != Running || h.TurnCheckAt == nil || now.Before(h.TurnCheckAt.AsTime()):
+		return nil
+	case h.TurnDeadline != nil && !now.Before(h.TurnDeadline.AsTime()):
+		return cl.timeout(ctx, g, v)
+	default:
+		return cl.remind(ctx, g, v)
+	}
+}
+
+// remind sends the next escalating reminder to the current players of the game.
+// Returns a ConflictError, if the game changed since read per the version.
+func (cl *GameClient[GT, G]) remind(ctx *gin.Context, g G, v *version) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	h := g.header()
+	h.TurnReminders++
+	h.TurnCheckAt = h.nextTurnCheck()
+
+	// Only the rev and index are updated, thereby leaving cached actions of current players intact.
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		if err := cl.txCheckVersion(ctx, tx, v); err != nil {
+			return err
+		}
+
+		if err := cl.txUpdateRev(ctx, tx, g); err != nil {
+			return err
+		}
+
+		if err := cl.txUpdateIndex(ctx, tx, g); err != nil {
+			return err
+		}
+		return cl.txNotify(tx, reminderNotification(g))
+	}); err != nil {
+		return err
+	}
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id()})
+	cl.kickOutbox()
+	return nil
+}
+
+// reminderNotification returns the reminder for the current players of the game
+func reminderNotification[GT any, G Gamer[GT]](g G) notification {
+	h := g.header()
+	return eventNotification(TurnReminderEvent, h.Type, g.id(), recipientsFor(h, h.CPIDS), H{
+		"Game":     h.Title,
+		"Deadline": h.TurnDeadline.AsTime().UTC().Format(time.RFC1123),
+		"Repeat":   h.TurnReminders > 1,
+		"Final":    h.TurnReminders >= len(reminderFractions),
+	})
+}
+
+// timeout takes the timeout action of the game against the current players.
+// Returns a ConflictError, if the game changed since read per the version.
+func (cl *GameClient[GT, G]) timeout(ctx *gin.Context, g G, v *version) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if passer, ok := any(g).(AutoPasser); ok && g.header().TimeoutAction == AutoPass {
+		return cl.autoPass(ctx, g, v, passer)
+	}
+	return cl.autoDrop(ctx, g, v)
+}
+
+func (cl *GameClient[GT, G]) autoPass(ctx *gin.Context, g G, v *version, passer AutoPasser) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	pids := slices.Clone(g.header().CPIDS)
+	next, err := passer.AutoPass(ctx, pids)
+	if err != nil {
+		return err
+	}
+
+	for _, pid := range pids {
+		g.updateStatsFor(pid)
+	}
+
+	if len(next) == 0 {
+		return cl.endGame(ctx, g, 0, v)
+	}
+
+	notify := g.SetCurrentPlayers(next...)
+	return cl.commit(ctx, g, 0, v, turnNotification(g, notify))
+}
+
+func (cl *GameClient[GT, G]) autoDrop(ctx *gin.Context, g G, v *version) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	h := g.header()
+	h.DroppedIDS = g.UIDSForPIDS(h.CPIDS)
+	h.Status = Abandoned
+	h.stopTurnClock()
+	h.UpdatedAt = timestamppb.Now()
+
+	abandoned := eventNotification(GameAbandonedEvent, h.Type, g.id(), recipientsFor(h, h.allPIDS()), H{"Game": h.Title})
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		t, err := cl.txGetTournamentOf(tx, h)
+		if err != nil {
+			return err
+		}
+
+		if err := cl.txCheckVersion(ctx, tx, v); err != nil {
+			return err
+		}
+
+		if err := cl.txSave(ctx, tx, g, 0); err != nil {
+			return err
+		}
+
+		if _, err := cl.txRecordTournamentGame(ctx, tx, h, t); err != nil {
+			return err
+		}
+		return cl.txNotify(tx, abandoned)
+	}); err != nil {
+		return err
+	}
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id()})
+	cl.kickOutbox()
+	return nil
+}
@@ -0,0 +1,87 @@
+package sn
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCommitRetainsTurnReminders(t *testing.T) {
+	cl := newTestClient(t)
+
+	ctx := context.Background()
+	h := Header{Type: "test", NumPlayers: 2, TurnLimit: time.Hour}
+	h.addUser(testUser(1))
+	h.addUser(testUser(2))
+	g, _, err := cl.startGame(ctx, h)
+	if err != nil {
+		t.Fatalf("startGame: %v", err)
+	}
+	g.setID(testGID)
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return cl.txSaveStarted(ctx, tx, g, 0)
+	}); err != nil {
+		t.Fatalf("txSaveStarted: %v", err)
+	}
+	uid := g.header().UIDFor(g.header().CPIDS[0])
+
+	count := func(g *testGame, _ *gin.Context, _ *User) (Result, error) {
+		g.State.Count++
+		return Result{}, nil
+	}
+	cl.Router.PUT("/cache/:id", cl.CachedHandler(count))
+	cl.Router.PUT("/commit/:id", cl.CommitHandler(count))
+	cl.Router.PUT("/remind/:id", func(ctx *gin.Context) {
+		var stack Stack
+		if err := cl.store.GetStack(ctx, testGID, 0, &stack); err != nil {
+			t.Fatal(err)
+		}
+
+		g, err := cl.getRev(ctx, testGID, stack.Current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.setStack(&stack)
+
+		if err := cl.remind(ctx, g, versionOf(g, 0)); err != nil {
+			t.Fatalf("remind: %v", err)
+		}
+	})
+
+	// the current player caches an action, then is reminded of the turn, then commits the cached game
+	for _, p := range []string{"/cache/", "/remind/", "/commit/"} {
+		if w := serve(cl, http.MethodPut, p+testGID, uid, ""); w.Code != http.StatusOK || w.Header().Get(ErrorCodeHeader) != "" {
+			t.Fatalf("%s: status = %d: %s", p, w.Code, w.Body)
+		}
+	}
+
+	i := getTestIndex(t, cl)
+	if i.TurnReminders != 1 {
+		t.Errorf("TurnReminders = %d, want 1", i.TurnReminders)
+	}
+
+	if want := i.nextTurnCheck(); !i.TurnCheckAt.AsTime().Equal(want.AsTime()) {
+		t.Errorf("TurnCheckAt = %v, want %v", i.TurnCheckAt.AsTime(), want.AsTime())
+	}
+}
+
+func TestAddUserPadsEmailReminders(t *testing.T) {
+	// UserEmailReminders is absent from headers created prior to its introduction
+	h := Header{UserIDS: []UID{1, 2}, UserNames: []string{"user1", "user2"}, UserEmails: []string{"", ""},
+		UserEmailHashes: []string{"", ""}, UserEmailNotifications: []bool{false, false}, UserGravTypes: []string{"", ""}}
+
+	u := testUser(3)
+	u.EmailReminders = true
+	h.addUser(u)
+	if !h.emailRemindersAt(2) || h.emailRemindersAt(0) {
+		t.Errorf("UserEmailReminders = %v, want reminders for the added user only", h.UserEmailReminders)
+	}
+
+	h.removeUser(testUser(1))
+	if len(h.UserEmailReminders) != len(h.UserIDS) || !h.emailRemindersAt(1) {
+		t.Errorf("UserEmailReminders = %v, UserIDS = %v, want reminders for user 3 only", h.UserEmailReminders, h.UserIDS)
+	}
+}
@@ -1,147 +1,55 @@
 package sn
 
 import (
-	"context"
-	"fmt"
-	"time"
-
-	"cloud.google.com/go/firestore"
-	"github.com/elliotchance/pie/v2"
-	"github.com/gin-gonic/gin"
 	elogo "github.com/kortemy/elo-go"
 )
 
-type elo struct {
-	UID       UID
-	Name      string
-	EmailHash string
-	GravType  string
-	Rating    int
-	UpdatedAt time.Time
-}
-
-func newEloDefault(u *User) elo {
-	const defaultRating = 1500
-	return elo{
-		UID:       u.ID,
-		Name:      u.Name,
-		EmailHash: u.EmailHash,
-		GravType:  u.GravType,
-		Rating:    defaultRating,
-		UpdatedAt: time.Now(),
-	}
-}
-
-func (cl *GameClient[GT, G]) eloDocRef(uid UID) *firestore.DocumentRef {
-	return cl.eloCollectionRef().Doc(fmt.Sprintf("%d", uid))
-}
+// EloRater provides a pairwise Elo rating system.
+// Each player is treated as having played each other player of the game,
+// winning against lower placed players, drawing against equally placed players,
+// and losing against higher placed players.
+type EloRater struct{}
 
-func (cl *GameClient[GT, G]) eloCollectionRef() *firestore.CollectionRef {
-	return cl.FS.Collection("Elo")
+// System implements Rater interface
+func (EloRater) System() string {
+	return "elo"
 }
 
-func (cl *GameClient[GT, G]) eloHistoryRef(uid UID) *firestore.CollectionRef {
-	return cl.eloDocRef(uid).Collection("History")
+// Initial implements Rater interface
+func (EloRater) Initial() Rating {
+	const defaultRating = 1500
+	return Rating{Rating: defaultRating, Mu: defaultRating}
 }
 
-type (
-	eloMap    map[UID]elo
-	placesMap map[UID]int
-)
-
-// Firestore only supports string values for map keys
-type placesSMap map[string]int
-
-func updateEloFor(ctx context.Context, uid1 UID, elos eloMap, places placesMap) int {
-	Debugf(ctx, msgEnter)
-	defer Debugf(ctx, msgExit)
-
-	var delta int
+// Rate implements Rater interface
+func (EloRater) Rate(ratings []Rating, places []int) []Rating {
 	elo := elogo.NewElo()
-	for uid2 := range elos {
-		if uid1 == uid2 {
-			continue
-		}
-		score := 0.0
-		if places[uid1] == places[uid2] {
-			score = 0.5
-		}
-		// places are essentially first, second, third, etc.
-		// thus, a lower place indicates better performance
-		if places[uid1] < places[uid2] {
-			score = 1
-		}
-		delta += elo.RatingDelta(elos[uid1].Rating, elos[uid2].Rating, score)
-	}
-	return elos[uid1].Rating + delta
-}
-
-func (cl *GameClient[GT, G]) txSaveElos(tx *firestore.Transaction, elos []elo) error {
-	for i, elo := range elos {
-		if err := tx.Set(cl.eloDocRef(elos[i].UID), elo); err != nil {
-			return err
-		}
-		if err := tx.Create(cl.eloHistoryRef(elos[i].UID).NewDoc(), elo); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (cl *GameClient[GT, G]) getElos(ctx *gin.Context, us ...*User) ([]elo, error) {
-	Debugf(ctx, msgEnter)
-	defer Debugf(ctx, msgExit)
-
-	refs := pie.Map(us, func(u *User) *firestore.DocumentRef { return cl.eloDocRef(u.ID) })
-	snaps, err := cl.FS.GetAll(ctx, refs)
-	if err != nil {
-		return nil, err
-	}
-
-	elos := make([]elo, len(snaps))
-	for i, snap := range snaps {
-		if !snap.Exists() {
-			elos[i] = newEloDefault(us[i])
-			continue
-		}
-
-		var elo elo
-		if err := snap.DataTo(&elo); err != nil {
-			return nil, err
-		}
-		elos[i] = elo
+	rated := make([]Rating, len(ratings))
+	for i := range ratings {
+		var delta int
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+			delta += elo.RatingDelta(ratings[i].Rating, ratings[j].Rating, pairwiseScore(places[i], places[j]))
+		}
+		rated[i] = ratings[i]
+		rated[i].Rating += delta
+		rated[i].Mu = float64(rated[i].Rating)
+	}
+	return rated
+}
+
+// pairwiseScore returns 1 if place1 is better than place2, 0.5 if equal, and 0 otherwise.
+// places are essentially first, second, third, etc.
+// thus, a lower place indicates better performance
+func pairwiseScore(place1, place2 int) float64 {
+	switch {
+	case place1 < place2:
+		return 1
+	case place1 == place2:
+		return 0.5
+	default:
+		return 0
 	}
-	return elos, nil
-}
-
-// Update pulls current Elo from db and provides rating updates and deltas per results for users associated with uids.
-// Returns ratings, updates, and current Elo (not updated) in same order as supplied uids
-func (cl *GameClient[GT, G]) updateElo(ctx *gin.Context, us []*User, places placesMap) ([]elo, []elo, error) {
-	Debugf(ctx, msgEnter)
-	defer Debugf(ctx, msgExit)
-
-	oldElos, err := cl.getElos(ctx, us...)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	eloMap := make(eloMap, len(oldElos))
-	for i, elo := range oldElos {
-		eloMap[us[i].ID] = elo
-	}
-
-	t := time.Now()
-	newElos := make([]elo, len(us))
-	for i, u := range us {
-		newElos[i] = elo{
-			UID:       u.ID,
-			Name:      u.Name,
-			EmailHash: u.EmailHash,
-			GravType:  u.GravType,
-			Rating:    updateEloFor(ctx, u.ID, eloMap, places),
-			UpdatedAt: t,
-		}
-	}
-
-	return oldElos, newElos, nil
 }
@@ -1,8 +1,10 @@
 package sn
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -13,30 +15,261 @@ var (
 	// ErrValidation represents a validation error
 	ErrValidation = errors.New("validation error")
 
+	// ErrForbidden represents a user not permitted to perform an action
+	ErrForbidden = errors.New("forbidden")
+
 	// ErrPlayerNotFound represents a player not found validation error
 	ErrPlayerNotFound = fmt.Errorf("player not found: %w", ErrValidation)
 
-	// ErrNotAdmin represents a user not admin validation error
-	ErrNotAdmin = fmt.Errorf("current user is not admin: %w", ErrValidation)
+	// ErrNotAdmin represents a user not admin error
+	ErrNotAdmin = fmt.Errorf("current user is not admin: %w", ErrForbidden)
 
-	// ErrNotLoggedIn represents user not logged in validation error
-	ErrNotLoggedIn = fmt.Errorf("must login to access resource: %w", ErrValidation)
+	// ErrNotLoggedIn represents user not logged in error
+	ErrNotLoggedIn = fmt.Errorf("must login to access resource: %w", ErrForbidden)
 
 	// ErrUserNil represents user was expectantly nil
 	ErrUserNil = fmt.Errorf("user cannot be nil")
+
+	// ErrNoSpectators represents a game not permitting spectators error
+	ErrNoSpectators = fmt.Errorf("game does not permit spectators: %w", ErrForbidden)
+
+	// ErrNotFound represents a requested document was not found in the store
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict represents a change to a game conflicting with a concurrent change, see ConflictError
+	ErrConflict = errors.New("conflicting change")
+)
+
+// ErrorCode provides a stable, machine-readable classification of an error
+type ErrorCode string
+
+const (
+	ValidationCode ErrorCode = "validation"
+	NotFoundCode   ErrorCode = "not_found"
+	ForbiddenCode  ErrorCode = "forbidden"
+	ConflictCode   ErrorCode = "conflict"
+	InternalCode   ErrorCode = "internal"
+)
+
+// errorCodeFor returns the code and HTTP status of the error
+func errorCodeFor(err error) (ErrorCode, int) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.Is(err, ErrConflict):
+		return ConflictCode, http.StatusConflict
+	case errors.Is(err, ErrNotFound):
+		return NotFoundCode, http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return ForbiddenCode, http.StatusForbidden
+	case errors.Is(err, ErrValidation), errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// Malformed and missing request bodies, as reported by ctx.ShouldBind, are validation errors
+		return ValidationCode, http.StatusBadRequest
+	default:
+		return InternalCode, http.StatusInternalServerError
+	}
+}
+
+// FieldError provides a validation error of a field of a request.
+// Field is empty for errors not specific to a field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ErrorBody provides the structured form of an error returned by JErr
+type ErrorBody struct {
+	Code      ErrorCode
+	Message   string
+	Details   []FieldError `json:",omitempty"`
+	RequestID string
+}
+
+// VError collects validation errors, permitting all problems of a request to be reported at once
+type VError struct {
+	details []FieldError
+}
+
+// NewVError returns an empty VError
+func NewVError() *VError {
+	return new(VError)
+}
+
+// AddMessagef adds a validation error not specific to a field
+func (e *VError) AddMessagef(format string, args ...any) {
+	e.AddFieldf("", format, args...)
+}
+
+// AddFieldf adds a validation error of the field
+func (e *VError) AddFieldf(field, format string, args ...any) {
+	e.details = append(e.details, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Errors returns the messages of the validation errors
+func (e *VError) Errors() []string {
+	msgs := make([]string, len(e.details))
+	for i, d := range e.details {
+		msgs[i] = d.Message
+	}
+	return msgs
+}
+
+// Details returns the validation errors
+func (e *VError) Details() []FieldError {
+	return e.details
+}
+
+// Err returns nil if no validation errors were added, otherwise the VError
+func (e *VError) Err() error {
+	if e == nil || len(e.details) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *VError) Error() string {
+	return strings.Join(e.Errors(), "; ")
+}
+
+// Unwrap permits errors.Is(err, ErrValidation)
+func (e *VError) Unwrap() error {
+	return ErrValidation
+}
+
+// ErrorFormat provides the form in which JErr returns errors
+type ErrorFormat int
+
+const (
+	// CompatErrors returns errors with status 200 and the message under a 'Message' key,
+	// if a validation or forbidden error, otherwise under an 'Error' key.
+	// Code and RequestID keys are added alongside.
+	CompatErrors ErrorFormat = iota
+
+	// StructuredErrors returns errors with the status of the error and an ErrorBody under an 'Error' key.
+	// Messages of internal errors are logged, but not returned.
+	StructuredErrors
 )
 
-// JErr returns an error message via JSON
-// Error message returned with a 'Message' key if a validation error
-// Error message returned with a 'Error' key if another type of error
+const (
+	// RequestIDHeader provides the header via which the id of a request is received and returned
+	RequestIDHeader = "X-Request-ID"
+
+	// ErrorFormatHeader permits a request to select the error format, i.e., compat or structured,
+	// overriding the format set by WithErrorFormat
+	ErrorFormatHeader = "X-Error-Format"
+
+	// ErrorCodeHeader provides the code of an error returned by JErr
+	ErrorCodeHeader = "X-Error-Code"
+
+	requestIDKey       = "RequestID"
+	errorFormatKey     = "ErrorFormat"
+	maxRequestIDLength = 128
+)
+
+// WithErrorFormat sets the default form in which errors are returned.
+// Defaults to CompatErrors.
+func WithErrorFormat(f ErrorFormat) Option {
+	return func(cl *Client) *Client {
+		cl.errorFormat = f
+		return cl
+	}
+}
+
+// requestContext returns middleware setting the request id and error format of each request.
+// The request id is taken from the X-Request-ID header, if provided, and is returned via the same header.
+func (cl *Client) requestContext() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newDocID()
+		}
+		ctx.Set(requestIDKey, id)
+		ctx.Header(RequestIDHeader, id)
+
+		format := cl.errorFormat
+		switch strings.ToLower(ctx.GetHeader(ErrorFormatHeader)) {
+		case "structured":
+			format = StructuredErrors
+		case "compat":
+			format = CompatErrors
+		}
+		ctx.Set(errorFormatKey, format)
+		ctx.Next()
+	}
+}
+
+// RequestID returns the id of the request
+func RequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
+
+// structuredErrors returns true if errors are returned to the request in the structured format
+func structuredErrors(ctx *gin.Context) bool {
+	format, _ := ctx.Get(errorFormatKey)
+	return format == StructuredErrors
+}
+
+// JErr returns an error via JSON in the error format of the request, see ErrorFormat
 func JErr(ctx *gin.Context, err error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	Debugf(ctx, "%v", err.Error())
-	if errors.Is(err, ErrValidation) {
-		ctx.JSON(http.StatusOK, gin.H{"Message": strings.TrimSuffix(err.Error(), ": "+ErrValidation.Error())})
+	// Recorded, so wrapping handlers, e.g., idempotent, may distinguish failed requests
+	_ = ctx.Error(err)
+
+	body, status := newErrorBody(ctx, err)
+	code := body.Code
+	if code == InternalCode {
+		Errorf(ctx, "request %s: %v", body.RequestID, err)
+	}
+	ctx.Header(ErrorCodeHeader, string(code))
+
+	if structuredErrors(ctx) {
+		if code == InternalCode {
+			body.Message = http.StatusText(status)
+		}
+		ctx.JSON(status, gin.H{"Error": body})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"Error": err.Error()})
+
+	resp := gin.H{"Code": code, "RequestID": body.RequestID}
+	if body.Details != nil {
+		resp["Details"] = body.Details
+	}
+	if code == ValidationCode || code == ForbiddenCode {
+		resp["Message"] = body.Message
+	} else {
+		resp["Error"] = body.Message
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// newErrorBody returns the structured form and HTTP status of the error
+func newErrorBody(ctx *gin.Context, err error) (ErrorBody, int) {
+	code, status := errorCodeFor(err)
+	body := ErrorBody{Code: code, Message: errorMessage(code, err), RequestID: RequestID(ctx)}
+
+	var verr *VError
+	if errors.As(err, &verr) {
+		body.Details = verr.Details()
+	}
+	return body, status
+}
+
+// errorMessage returns the message of the error, less the suffix of its sentinel error
+func errorMessage(code ErrorCode, err error) string {
+	msg := err.Error()
+	switch code {
+	case ValidationCode:
+		return strings.TrimSuffix(msg, ": "+ErrValidation.Error())
+	case ForbiddenCode:
+		return strings.TrimSuffix(msg, ": "+ErrForbidden.Error())
+	default:
+		return msg
+	}
 }
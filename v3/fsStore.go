@@ -0,0 +1,734 @@
+package sn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fsStore implements Store using Firestore
+type fsStore struct {
+	fs *firestore.Client
+}
+
+func newFSStore(fs *firestore.Client) *fsStore {
+	return &fsStore{fs: fs}
+}
+
+// fsErr wraps not found errors returned by Firestore with ErrNotFound
+func fsErr(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+func (s *fsStore) getDoc(ctx context.Context, ref *firestore.DocumentRef, dst any) error {
+	snap, err := ref.Get(ctx)
+	if err != nil {
+		return fsErr(err)
+	}
+	return snap.DataTo(dst)
+}
+
+func (s *fsStore) getDocs(ctx context.Context, q firestore.Query) ([]Doc, error) {
+	snaps, err := q.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Doc, len(snaps))
+	for i, snap := range snaps {
+		docs[i] = fsDoc{snap}
+	}
+	return docs, nil
+}
+
+// fsDoc implements Doc interface for Firestore document snapshots
+type fsDoc struct {
+	snap *firestore.DocumentSnapshot
+}
+
+// ID implements Doc interface
+func (d fsDoc) ID() string {
+	return d.snap.Ref.ID
+}
+
+// DataTo implements Doc interface
+func (d fsDoc) DataTo(dst any) error {
+	return d.snap.DataTo(dst)
+}
+
+func (s *fsStore) gameCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Game")
+}
+
+func (s *fsStore) gameDocRef(gid string) *firestore.DocumentRef {
+	return s.gameCollectionRef().Doc(gid)
+}
+
+func (s *fsStore) revCollectionRef(gid string) *firestore.CollectionRef {
+	return s.gameDocRef(gid).Collection("Rev")
+}
+
+func (s *fsStore) revDocRef(gid string, rev Rev) *firestore.DocumentRef {
+	return s.revCollectionRef(gid).Doc(rev.toString())
+}
+
+func (s *fsStore) cachedCollectionRef(gid string, uid UID) *firestore.CollectionRef {
+	return s.gameDocRef(gid).Collection("CacheFor").Doc(uid.toString()).Collection("Rev")
+}
+
+func (s *fsStore) cachedDocRef(gid string, uid UID, rev Rev) *firestore.DocumentRef {
+	return s.cachedCollectionRef(gid, uid).Doc(rev.toString())
+}
+
+func (s *fsStore) viewDocRef(gid string, uid UID) *firestore.DocumentRef {
+	return s.gameDocRef(gid).Collection("For").Doc(uid.toString())
+}
+
+func (s *fsStore) messagesCollectionRef(gid string) *firestore.CollectionRef {
+	return s.gameDocRef(gid).Collection("Messages")
+}
+
+func (s *fsStore) messageDocRef(gid string, mid string) *firestore.DocumentRef {
+	return s.messagesCollectionRef(gid).Doc(mid)
+}
+
+func (s *fsStore) whispersCollectionRef(gid string) *firestore.CollectionRef {
+	return s.gameDocRef(gid).Collection("Whispers")
+}
+
+func (s *fsStore) whisperDocRef(gid string, mid string) *firestore.DocumentRef {
+	return s.whispersCollectionRef(gid).Doc(mid)
+}
+
+func (s *fsStore) idempotencyDocRef(gid string, id string) *firestore.DocumentRef {
+	return s.gameDocRef(gid).Collection("Idempotency").Doc(id)
+}
+
+func (s *fsStore) chatModerationDocRef(gid string) *firestore.DocumentRef {
+	return s.fs.Collection("ChatModeration").Doc(gid)
+}
+
+func (s *fsStore) indexDocRef(id string) *firestore.DocumentRef {
+	return s.fs.Collection("Index").Doc(id)
+}
+
+func (s *fsStore) stackCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Stack")
+}
+
+func (s *fsStore) stackDocRef(gid string, uid UID) *firestore.DocumentRef {
+	return s.stackCollectionRef().Doc(gid).Collection("For").Doc(uid.toString())
+}
+
+func (s *fsStore) invitationCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Invitation")
+}
+
+func (s *fsStore) invitationDocRef(id string) *firestore.DocumentRef {
+	return s.invitationCollectionRef().Doc(id)
+}
+
+func (s *fsStore) hashDocRef(id string) *firestore.DocumentRef {
+	return s.invitationDocRef(id).Collection("Hash").Doc("hash")
+}
+
+func (s *fsStore) tournamentCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Tournament")
+}
+
+func (s *fsStore) tournamentDocRef(id string) *firestore.DocumentRef {
+	return s.tournamentCollectionRef().Doc(id)
+}
+
+func (s *fsStore) queueCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Queue")
+}
+
+func (s *fsStore) queueDocRef(id string) *firestore.DocumentRef {
+	return s.queueCollectionRef().Doc(id)
+}
+
+func (s *fsStore) outboxCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Outbox")
+}
+
+func (s *fsStore) outboxDocRef(id string) *firestore.DocumentRef {
+	return s.outboxCollectionRef().Doc(id)
+}
+
+func (s *fsStore) digestDocRef(uid UID) *firestore.DocumentRef {
+	return s.fs.Collection("Digest").Doc(uid.toString())
+}
+
+func (s *fsStore) userDocRef(uid UID) *firestore.DocumentRef {
+	return s.fs.Collection("User").Doc(uid.toString())
+}
+
+func (s *fsStore) preferencesDocRef(uid UID) *firestore.DocumentRef {
+	return s.fs.Collection("Preferences").Doc(uid.toString())
+}
+
+func (s *fsStore) ratingDocRef(id string) *firestore.DocumentRef {
+	return s.fs.Collection("Rating").Doc(id)
+}
+
+func (s *fsStore) eloCollectionRef() *firestore.CollectionRef {
+	return s.fs.Collection("Elo")
+}
+
+func (s *fsStore) eloDocRef(uid UID) *firestore.DocumentRef {
+	return s.eloCollectionRef().Doc(uid.toString())
+}
+
+func (s *fsStore) eloHistoryRef(uid UID) *firestore.CollectionRef {
+	return s.eloDocRef(uid).Collection("History")
+}
+
+func (s *fsStore) ustatDocRef(uid UID) *firestore.DocumentRef {
+	return s.fs.Collection("UStat").Doc(uid.toString())
+}
+
+func (s *fsStore) subCollectionRef(gid string) *firestore.CollectionRef {
+	return s.gameDocRef(gid).Collection("Sub")
+}
+
+func (s *fsStore) subDocRef(gid string, uid UID) *firestore.DocumentRef {
+	return s.subCollectionRef(gid).Doc(uid.toString())
+}
+
+func (s *fsStore) subInvCollectionRef(id string) *firestore.CollectionRef {
+	return s.invitationDocRef(id).Collection("Sub")
+}
+
+func (s *fsStore) subInvDocRef(id string, uid UID) *firestore.DocumentRef {
+	return s.subInvCollectionRef(id).Doc(uid.toString())
+}
+
+// RunTransaction implements Store interface
+func (s *fsStore) RunTransaction(ctx context.Context, f func(context.Context, Tx) error) error {
+	return s.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return f(ctx, &fsTx{store: s, tx: tx})
+	})
+}
+
+// GetRev implements Store interface
+func (s *fsStore) GetRev(ctx context.Context, gid string, rev Rev, dst any) error {
+	return s.getDoc(ctx, s.revDocRef(gid, rev), dst)
+}
+
+// GetCached implements Store interface
+func (s *fsStore) GetCached(ctx context.Context, gid string, uid UID, rev Rev, dst any) error {
+	return s.getDoc(ctx, s.cachedDocRef(gid, uid, rev), dst)
+}
+
+// GetStack implements Store interface
+func (s *fsStore) GetStack(ctx context.Context, gid string, uid UID, dst *Stack) error {
+	return s.getDoc(ctx, s.stackDocRef(gid, uid), dst)
+}
+
+// GetIndex implements Store interface
+func (s *fsStore) GetIndex(ctx context.Context, id string, dst any) error {
+	return s.getDoc(ctx, s.indexDocRef(id), dst)
+}
+
+// ListIndexes implements Store interface
+func (s *fsStore) ListIndexes(ctx context.Context, q IndexQuery) ([]Doc, error) {
+	query := s.fs.Collection("Index").Query
+	if q.Status != NoStatus {
+		query = query.Where("Status", "==", q.Status)
+	}
+	if !q.CheckBy.IsZero() {
+		query = query.Where("TurnCheckAt", "<=", q.CheckBy)
+	}
+	if q.Type != NoType {
+		query = query.Where("Type", "==", q.Type)
+	}
+	// Firestore permits a single array-contains filter per query, and current players are players
+	switch {
+	case q.CurrentUserID != 0:
+		query = query.Where("CPUserIDS", "array-contains", q.CurrentUserID)
+	case q.UserID != 0:
+		query = query.Where("UserIDS", "array-contains", q.UserID)
+	}
+	if q.SortByUpdatedAt {
+		dir := firestore.Asc
+		if q.Desc {
+			dir = firestore.Desc
+		}
+		query = query.OrderBy("UpdatedAt", dir).OrderBy(firestore.DocumentID, dir)
+		if q.After != nil {
+			query = query.StartAfter(q.After.UpdatedAt, q.After.ID)
+		}
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	return s.getDocs(ctx, query)
+}
+
+// ListRatings implements Store interface
+func (s *fsStore) ListRatings(ctx context.Context, q RatingQuery) ([]Doc, error) {
+	return s.getDocs(ctx, ratingQuery(s.fs.Collection("Rating").Query, q))
+}
+
+// ListRatingHistory implements Store interface
+func (s *fsStore) ListRatingHistory(ctx context.Context, uid UID, q RatingQuery) ([]Doc, error) {
+	return s.getDocs(ctx, ratingQuery(s.eloHistoryRef(uid).Query, q))
+}
+
+func ratingQuery(query firestore.Query, q RatingQuery) firestore.Query {
+	if q.System != "" {
+		query = query.Where("System", "==", q.System)
+	}
+	if q.Type != NoType {
+		query = query.Where("Type", "==", q.Type)
+	}
+	if q.NumPlayers != 0 {
+		query = query.Where("NumPlayers", "==", q.NumPlayers)
+	}
+	if q.OrderBy != "" {
+		dir := firestore.Asc
+		if q.Desc {
+			dir = firestore.Desc
+		}
+		query = query.OrderBy(q.OrderBy, dir)
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	return query
+}
+
+// GetInvitation implements Store interface
+func (s *fsStore) GetInvitation(ctx context.Context, id string, dst any) error {
+	return s.getDoc(ctx, s.invitationDocRef(id), dst)
+}
+
+// SetInvitation implements Store interface
+func (s *fsStore) SetInvitation(ctx context.Context, id string, inv any) error {
+	_, err := s.invitationDocRef(id).Set(ctx, inv)
+	return err
+}
+
+// GetHash implements Store interface
+func (s *fsStore) GetHash(ctx context.Context, id string) ([]byte, error) {
+	snap, err := s.hashDocRef(id).Get(ctx)
+	if err != nil {
+		return nil, fsErr(err)
+	}
+
+	hashInf, err := snap.DataAt("Hash")
+	if err != nil {
+		return nil, err
+	}
+
+	hash, ok := hashInf.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type for stored hash")
+	}
+	return hash, nil
+}
+
+// GetTournament implements Store interface
+func (s *fsStore) GetTournament(ctx context.Context, id string, dst any) error {
+	return s.getDoc(ctx, s.tournamentDocRef(id), dst)
+}
+
+// ListQueue implements Store interface
+func (s *fsStore) ListQueue(ctx context.Context, t Type) ([]Doc, error) {
+	query := s.queueCollectionRef().Query
+	if t != NoType {
+		query = query.Where("Type", "==", t)
+	}
+	return s.getDocs(ctx, query)
+}
+
+// ListOutbox implements Store interface.
+// Requires a composite index on the DeadLettered and NextAttemptAt fields of the Outbox collection.
+func (s *fsStore) ListOutbox(ctx context.Context, due time.Time, limit int) ([]Doc, error) {
+	query := s.outboxCollectionRef().
+		Where("DeadLettered", "==", false).
+		Where("NextAttemptAt", "<=", due).
+		OrderBy("NextAttemptAt", firestore.Asc).
+		Limit(limit)
+	return s.getDocs(ctx, query)
+}
+
+// ListExpiredInvitations implements Store interface
+func (s *fsStore) ListExpiredInvitations(ctx context.Context, due time.Time) ([]Doc, error) {
+	return s.getDocs(ctx, s.invitationCollectionRef().Where("ExpiresAt", "<=", due))
+}
+
+// ListInvitations implements Store interface.
+// Requires composite indices on the queried fields and CreatedAt of the Invitation collection.
+func (s *fsStore) ListInvitations(ctx context.Context, q InvitationQuery) ([]Doc, error) {
+	query := s.invitationCollectionRef().Query
+	if q.Status != NoStatus {
+		query = query.Where("Status", "==", q.Status)
+	}
+	if q.Type != NoType {
+		query = query.Where("Type", "==", q.Type)
+	}
+	if q.NumPlayers != 0 {
+		query = query.Where("NumPlayers", "==", q.NumPlayers)
+	}
+	if q.Private != nil {
+		query = query.Where("Private", "==", *q.Private)
+	}
+	if q.CreatorID != 0 {
+		query = query.Where("CreatorID", "==", q.CreatorID)
+	}
+	if q.UserID != 0 {
+		query = query.Where("UserIDS", "array-contains", q.UserID)
+	}
+	query = query.OrderBy("CreatedAt", firestore.Desc)
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	return s.getDocs(ctx, query)
+}
+
+// ListInvites implements Store interface
+func (s *fsStore) ListInvites(ctx context.Context, uid UID) ([]Doc, error) {
+	return s.getDocs(ctx, s.invitationCollectionRef().Where("InviteeIDS", "array-contains", uid))
+}
+
+// ListDigests implements Store interface
+func (s *fsStore) ListDigests(ctx context.Context, due time.Time) ([]Doc, error) {
+	return s.getDocs(ctx, s.fs.Collection("Digest").Where("SendAt", "<=", due))
+}
+
+// GetPreferences implements Store interface
+func (s *fsStore) GetPreferences(ctx context.Context, uid UID, dst any) error {
+	return s.getDoc(ctx, s.preferencesDocRef(uid), dst)
+}
+
+// SetPreferences implements Store interface
+func (s *fsStore) SetPreferences(ctx context.Context, uid UID, p any) error {
+	_, err := s.preferencesDocRef(uid).Set(ctx, p)
+	return err
+}
+
+// GetUser implements Store interface
+func (s *fsStore) GetUser(ctx context.Context, uid UID, dst any) error {
+	return s.getDoc(ctx, s.userDocRef(uid), dst)
+}
+
+// GetRating implements Store interface
+func (s *fsStore) GetRating(ctx context.Context, id string, dst any) error {
+	return s.getDoc(ctx, s.ratingDocRef(id), dst)
+}
+
+// GetLegacyElo implements Store interface
+func (s *fsStore) GetLegacyElo(ctx context.Context, uid UID, dst any) error {
+	return s.getDoc(ctx, s.eloDocRef(uid), dst)
+}
+
+// GetUStat implements Store interface
+func (s *fsStore) GetUStat(ctx context.Context, uid UID, dst any) error {
+	return s.getDoc(ctx, s.ustatDocRef(uid), dst)
+}
+
+// GetSubs implements Store interface
+func (s *fsStore) GetSubs(ctx context.Context, gid string, uid UID, dst any) error {
+	return s.getDoc(ctx, s.subDocRef(gid, uid), dst)
+}
+
+// SetSubs implements Store interface
+func (s *fsStore) SetSubs(ctx context.Context, gid string, uid UID, subs any) error {
+	_, err := s.subDocRef(gid, uid).Set(ctx, subs)
+	return err
+}
+
+// DeleteSubs implements Store interface
+func (s *fsStore) DeleteSubs(ctx context.Context, gid string, uid UID) error {
+	_, err := s.subDocRef(gid, uid).Delete(ctx)
+	return err
+}
+
+// SetInvitationSubs implements Store interface
+func (s *fsStore) SetInvitationSubs(ctx context.Context, id string, uid UID, subs any) error {
+	_, err := s.subInvDocRef(id, uid).Set(ctx, subs)
+	return err
+}
+
+// AddMessage implements Store interface
+func (s *fsStore) AddMessage(ctx context.Context, gid string, m any) (string, error) {
+	ref := s.messagesCollectionRef(gid).NewDoc()
+	if _, err := ref.Create(ctx, m); err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// MarkRead implements Store interface
+func (s *fsStore) MarkRead(ctx context.Context, gid string, mid string, uid UID) error {
+	_, err := s.messageDocRef(gid, mid).Update(ctx, []firestore.Update{
+		{Path: "Read", Value: firestore.ArrayUnion(uid)},
+	})
+	return fsErr(err)
+}
+
+// ListMessages implements Store interface
+func (s *fsStore) ListMessages(ctx context.Context, gid string) ([]Doc, error) {
+	return s.getDocs(ctx, s.messagesCollectionRef(gid).OrderBy("CreatedAt", firestore.Asc))
+}
+
+// AddWhisper implements Store interface
+func (s *fsStore) AddWhisper(ctx context.Context, gid string, m any) (string, error) {
+	ref := s.whispersCollectionRef(gid).NewDoc()
+	if _, err := ref.Create(ctx, m); err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// ListWhispers implements Store interface
+func (s *fsStore) ListWhispers(ctx context.Context, gid string, uid UID) ([]Doc, error) {
+	query := s.whispersCollectionRef(gid).
+		Where("Audience", "array-contains", uid).
+		OrderBy("CreatedAt", firestore.Asc)
+	return s.getDocs(ctx, query)
+}
+
+// GetChatModeration implements Store interface
+func (s *fsStore) GetChatModeration(ctx context.Context, gid string, dst any) error {
+	return s.getDoc(ctx, s.chatModerationDocRef(gid), dst)
+}
+
+// SetIdempotencyRecord implements Store interface
+func (s *fsStore) SetIdempotencyRecord(ctx context.Context, gid string, id string, rec any) error {
+	_, err := s.idempotencyDocRef(gid, id).Set(ctx, rec)
+	return err
+}
+
+// DeleteIdempotencyRecord implements Store interface
+func (s *fsStore) DeleteIdempotencyRecord(ctx context.Context, gid string, id string) error {
+	_, err := s.idempotencyDocRef(gid, id).Delete(ctx)
+	return err
+}
+
+// fsTx implements Tx using a Firestore transaction
+type fsTx struct {
+	store *fsStore
+	tx    *firestore.Transaction
+}
+
+func (t *fsTx) getDoc(ref *firestore.DocumentRef, dst any) error {
+	snap, err := t.tx.Get(ref)
+	if err != nil {
+		return fsErr(err)
+	}
+	return snap.DataTo(dst)
+}
+
+// GetIndex implements Tx interface
+func (t *fsTx) GetIndex(id string, dst any) error {
+	return t.getDoc(t.store.indexDocRef(id), dst)
+}
+
+// SetRev implements Tx interface
+func (t *fsTx) SetRev(gid string, rev Rev, g any) error {
+	return t.tx.Set(t.store.revDocRef(gid, rev), g)
+}
+
+// SetCached implements Tx interface
+func (t *fsTx) SetCached(gid string, uid UID, rev Rev, g any) error {
+	return t.tx.Set(t.store.cachedDocRef(gid, uid, rev), g)
+}
+
+// DeleteCached implements Tx interface
+func (t *fsTx) DeleteCached(gid string, uid UID, rev Rev) error {
+	return t.tx.Delete(t.store.cachedDocRef(gid, uid, rev))
+}
+
+// GetStack implements Tx interface
+func (t *fsTx) GetStack(gid string, uid UID, dst *Stack) error {
+	return t.getDoc(t.store.stackDocRef(gid, uid), dst)
+}
+
+// SetStack implements Tx interface
+func (t *fsTx) SetStack(gid string, uid UID, stack *Stack) error {
+	return t.tx.Set(t.store.stackDocRef(gid, uid), stack)
+}
+
+// SetView implements Tx interface
+func (t *fsTx) SetView(gid string, uid UID, v any) error {
+	return t.tx.Set(t.store.viewDocRef(gid, uid), v)
+}
+
+// SetIndex implements Tx interface
+func (t *fsTx) SetIndex(id string, i any) error {
+	return t.tx.Set(t.store.indexDocRef(id), i)
+}
+
+// GetInvitation implements Tx interface
+func (t *fsTx) GetInvitation(id string, dst any) error {
+	return t.getDoc(t.store.invitationDocRef(id), dst)
+}
+
+// SetInvitation implements Tx interface
+func (t *fsTx) SetInvitation(id string, inv any) error {
+	return t.tx.Set(t.store.invitationDocRef(id), inv)
+}
+
+// CreateInvitation implements Tx interface
+func (t *fsTx) CreateInvitation(inv any) (string, error) {
+	ref := t.store.invitationCollectionRef().NewDoc()
+	if err := t.tx.Create(ref, inv); err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// DeleteInvitation implements Tx interface
+func (t *fsTx) DeleteInvitation(id string) error {
+	return t.tx.Delete(t.store.invitationDocRef(id))
+}
+
+// CreateHash implements Tx interface
+func (t *fsTx) CreateHash(id string, hash []byte) error {
+	return t.tx.Create(t.store.hashDocRef(id), H{"Hash": hash})
+}
+
+// SetHash implements Tx interface
+func (t *fsTx) SetHash(id string, hash []byte) error {
+	return t.tx.Set(t.store.hashDocRef(id), H{"Hash": hash})
+}
+
+// DeleteHash implements Tx interface
+func (t *fsTx) DeleteHash(id string) error {
+	return t.tx.Delete(t.store.hashDocRef(id))
+}
+
+// GetTournament implements Tx interface
+func (t *fsTx) GetTournament(id string, dst any) error {
+	return t.getDoc(t.store.tournamentDocRef(id), dst)
+}
+
+// CreateTournament implements Tx interface
+func (t *fsTx) CreateTournament(tour any) (string, error) {
+	ref := t.store.tournamentCollectionRef().NewDoc()
+	if err := t.tx.Create(ref, tour); err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// SetTournament implements Tx interface
+func (t *fsTx) SetTournament(id string, tour any) error {
+	return t.tx.Set(t.store.tournamentDocRef(id), tour)
+}
+
+// GetQueueEntry implements Tx interface
+func (t *fsTx) GetQueueEntry(id string, dst any) error {
+	return t.getDoc(t.store.queueDocRef(id), dst)
+}
+
+// SetQueueEntry implements Tx interface
+func (t *fsTx) SetQueueEntry(id string, e any) error {
+	return t.tx.Set(t.store.queueDocRef(id), e)
+}
+
+// DeleteQueueEntry implements Tx interface
+func (t *fsTx) DeleteQueueEntry(id string) error {
+	return t.tx.Delete(t.store.queueDocRef(id))
+}
+
+// GetOutboxEntry implements Tx interface
+func (t *fsTx) GetOutboxEntry(id string, dst any) error {
+	return t.getDoc(t.store.outboxDocRef(id), dst)
+}
+
+// SetOutboxEntry implements Tx interface
+func (t *fsTx) SetOutboxEntry(id string, n any) error {
+	return t.tx.Set(t.store.outboxDocRef(id), n)
+}
+
+// DeleteOutboxEntry implements Tx interface
+func (t *fsTx) DeleteOutboxEntry(id string) error {
+	return t.tx.Delete(t.store.outboxDocRef(id))
+}
+
+// GetIdempotencyRecord implements Tx interface
+func (t *fsTx) GetIdempotencyRecord(gid string, id string, dst any) error {
+	return t.getDoc(t.store.idempotencyDocRef(gid, id), dst)
+}
+
+// SetIdempotencyRecord implements Tx interface
+func (t *fsTx) SetIdempotencyRecord(gid string, id string, rec any) error {
+	return t.tx.Set(t.store.idempotencyDocRef(gid, id), rec)
+}
+
+// GetDigest implements Tx interface
+func (t *fsTx) GetDigest(uid UID, dst any) error {
+	return t.getDoc(t.store.digestDocRef(uid), dst)
+}
+
+// SetDigest implements Tx interface
+func (t *fsTx) SetDigest(uid UID, d any) error {
+	return t.tx.Set(t.store.digestDocRef(uid), d)
+}
+
+// DeleteDigest implements Tx interface
+func (t *fsTx) DeleteDigest(uid UID) error {
+	return t.tx.Delete(t.store.digestDocRef(uid))
+}
+
+// GetMessage implements Tx interface
+func (t *fsTx) GetMessage(gid string, mid string, dst any) error {
+	return t.getDoc(t.store.messageDocRef(gid, mid), dst)
+}
+
+// SetMessage implements Tx interface
+func (t *fsTx) SetMessage(gid string, mid string, m any) error {
+	return t.tx.Set(t.store.messageDocRef(gid, mid), m)
+}
+
+// GetWhisper implements Tx interface
+func (t *fsTx) GetWhisper(gid string, mid string, dst any) error {
+	return t.getDoc(t.store.whisperDocRef(gid, mid), dst)
+}
+
+// SetWhisper implements Tx interface
+func (t *fsTx) SetWhisper(gid string, mid string, m any) error {
+	return t.tx.Set(t.store.whisperDocRef(gid, mid), m)
+}
+
+// GetChatModeration implements Tx interface
+func (t *fsTx) GetChatModeration(gid string, dst any) error {
+	return t.getDoc(t.store.chatModerationDocRef(gid), dst)
+}
+
+// SetChatModeration implements Tx interface
+func (t *fsTx) SetChatModeration(gid string, m any) error {
+	return t.tx.Set(t.store.chatModerationDocRef(gid), m)
+}
+
+// SetRating implements Tx interface
+func (t *fsTx) SetRating(id string, r any) error {
+	return t.tx.Set(t.store.ratingDocRef(id), r)
+}
+
+// AddEloHistory implements Tx interface
+func (t *fsTx) AddEloHistory(uid UID, e any) error {
+	return t.tx.Create(t.store.eloHistoryRef(uid).NewDoc(), e)
+}
+
+// SetUStat implements Tx interface
+func (t *fsTx) SetUStat(uid UID, stat any) error {
+	return t.tx.Set(t.store.ustatDocRef(uid), stat)
+}
@@ -36,13 +36,22 @@ func NewGameClient[GT any, G Gamer[GT]](ctx context.Context, opts ...Option) (*G
 		return nil, fmt.Errorf("error getting Auth client: %w", err)
 	}
 
-	if cl.FS, err = app.Firestore(ctx); err != nil {
-		return nil, fmt.Errorf("unable to connect to firestore database: %w", err)
+	if cl.store == nil {
+		if cl.FS, err = app.Firestore(ctx); err != nil {
+			return nil, fmt.Errorf("unable to connect to firestore database: %w", err)
+		}
+		cl.store = newFSStore(cl.FS)
 	}
 
 	if cl.FCM, err = app.Messaging(ctx); err != nil {
 		return nil, fmt.Errorf("unable to connect to firebase messaging: %w", err)
 	}
+
+	// the outbox worker outlives the context used to create the client, and is stopped by Close
+	outboxCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cl.stopOutbox = cancel
+	go cl.runOutbox(outboxCtx)
+
 	return cl.addRoutes(cl.prefix), nil
 }
 
@@ -89,13 +98,8 @@ func (cl *GameClient[GT, G]) getRev(ctx *gin.Context, gid string, rev Rev) (G, e
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.revDocRef(gid, rev).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	g := G(new(GT))
-	if err := snap.DataTo(g); err != nil {
+	if err := cl.store.GetRev(ctx, gid, rev, g); err != nil {
 		return nil, err
 	}
 
@@ -107,13 +111,8 @@ func (cl *GameClient[GT, G]) getCached(ctx *gin.Context, gid string, uid UID, re
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.cachedDocRef(gid, uid, rev).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	g := G(new(GT))
-	if err := snap.DataTo(g); err != nil {
+	if err := cl.store.GetCached(ctx, gid, uid, rev, g); err != nil {
 		return nil, err
 	}
 
@@ -125,13 +124,8 @@ func (cl *GameClient[GT, G]) getIndex(ctx *gin.Context, id string) (*index, erro
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.indexDocRef(id).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	i := new(index)
-	if err := snap.DataTo(i); err != nil {
+	if err := cl.store.GetIndex(ctx, id, i); err != nil {
 		return nil, err
 	}
 
@@ -139,17 +133,12 @@ func (cl *GameClient[GT, G]) getIndex(ctx *gin.Context, id string) (*index, erro
 	return i, nil
 }
 
-func (cl *GameClient[GT, G]) txGetIndex(ctx context.Context, tx *firestore.Transaction, id string) (*index, error) {
+func (cl *GameClient[GT, G]) txGetIndex(ctx context.Context, tx Tx, id string) (*index, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := tx.Get(cl.indexDocRef(id))
-	if err != nil {
-		return nil, err
-	}
-
 	i := new(index)
-	if err := snap.DataTo(i); err != nil {
+	if err := tx.GetIndex(id, i); err != nil {
 		return nil, err
 	}
 
@@ -157,16 +146,18 @@ func (cl *GameClient[GT, G]) txGetIndex(ctx context.Context, tx *firestore.Trans
 	return i, nil
 }
 
-func (cl *GameClient[GT, G]) cacheRev(ctx *gin.Context, g G, uid UID) error {
+// cacheRev caches the game for the user.
+// Returns a ConflictError if the game changed since the version was read.
+func (cl *GameClient[GT, G]) cacheRev(ctx *gin.Context, g G, uid UID, v *version) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
-		if err := cl.txUpdateViews(ctx, tx, g, uid); err != nil {
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		if err := cl.txCheckVersion(ctx, tx, v); err != nil {
 			return err
 		}
 
-		if err := cl.txSaveStack(ctx, tx, g, uid); err != nil {
+		if err := cl.txUpdateViews(ctx, tx, g, uid); err != nil {
 			return err
 		}
 
@@ -175,17 +166,22 @@ func (cl *GameClient[GT, G]) cacheRev(ctx *gin.Context, g G, uid UID) error {
 		}
 
 		return cl.txCacheRev(ctx, tx, g, uid)
-	})
+	}); err != nil {
+		return err
+	}
+
+	cl.broker.publish(gameEvent{Kind: CacheEvent, GID: g.id(), UID: uid})
+	return nil
 }
 
-func (cl *GameClient[GT, G]) txCacheRev(ctx context.Context, tx *firestore.Transaction, g G, uid UID) error {
+func (cl *GameClient[GT, G]) txCacheRev(ctx context.Context, tx Tx, g G, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	return tx.Set(cl.cachedDocRef(g.id(), uid, g.stack().Current), g)
+	return tx.SetCached(g.id(), uid, g.stack().Current, g)
 }
 
-func (cl *GameClient[GT, G]) endGame(ctx *gin.Context, g G, uid UID) error {
+func (cl *GameClient[GT, G]) endGame(ctx *gin.Context, g G, uid UID, v *version) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
@@ -201,16 +197,26 @@ func (cl *GameClient[GT, G]) endGame(ctx *gin.Context, g G, uid UID) error {
 	}
 	stats = g.updateUStats(stats, g.playerStats(), g.playerUIDS())
 
-	oldElos, newElos, err := cl.updateElo(ctx, g.header().users(), places)
+	oldRatings, newRatings, err := cl.updateRatings(ctx, g.header(), places)
 	if err != nil {
 		return err
 	}
 
-	rs := g.getResults(ctx, oldElos, newElos)
+	rs := g.getResults(ctx, oldRatings, newRatings)
 	g.newEntry("game-results", H{"Results": rs})
 
-	if err := cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
-		if err := cl.txCommit(ctx, tx, g, uid); err != nil {
+	ended := g.endedNotification(rs)
+
+	stack := *g.stack()
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		// tournament is read before the writes of txCommit, and recorded in the same transaction,
+		// thereby ensuring the bracket of the tournament advances with the ended game
+		t, err := cl.txGetTournamentOf(tx, g.header())
+		if err != nil {
+			return err
+		}
+
+		if err := cl.txCommit(ctx, tx, g, uid, v, stack); err != nil {
 			return err
 		}
 
@@ -218,14 +224,17 @@ func (cl *GameClient[GT, G]) endGame(ctx *gin.Context, g G, uid UID) error {
 			return err
 		}
 
-		return cl.txSaveElos(tx, newElos)
+		if err := cl.txSaveRatings(tx, newRatings); err != nil {
+			return err
+		}
+		if _, err := cl.txRecordTournamentGame(ctx, tx, g.header(), t); err != nil {
+			return err
+		}
+		return cl.txNotify(tx, ended)
 	}); err != nil {
 		return err
 	}
-
-	if err := g.sendEndGameNotifications(ctx, rs); err != nil {
-		// log but otherwise ignore send errors
-		Warnf(ctx, "%v", err.Error())
-	}
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id(), UID: uid})
+	cl.kickOutbox()
 	return nil
 }
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"strings"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -16,12 +15,39 @@ type Result struct {
 	Message string
 }
 
+// ActionResponse provides the response of game actions and stack operations,
+// permitting clients to update from a single response.
+// Log provides the entries of the game log added or updated by the action,
+// which replace the entries of the game log from LogOffset.
+type ActionResponse[GT any] struct {
+	Game      *GT
+	Stack     StackState
+	Log       glog
+	LogOffset int
+	Message   string `json:",omitempty"`
+}
+
+// actionResponse returns the view of the game for the user, the stack of the user,
+// and the entries of the game log added or updated since the mark
+func (cl *GameClient[GT, G]) actionResponse(g G, uid UID, m logMark, msg string) ActionResponse[GT] {
+	offset, entries := g.gameLog().since(m)
+	return ActionResponse[GT]{
+		Game:      cl.viewFor(g, uid),
+		Stack:     g.stack().state(),
+		Log:       entries,
+		LogOffset: offset,
+		Message:   strings.TrimSpace(msg),
+	}
+}
+
 // ActionFunc provides a func type for game actions executed by CachedHandler or SavedHandler
 type ActionFunc[GT any, G Gamer[GT]] func(G, *gin.Context, *User) (Result, error)
 
-// CachedHandler provides a general purpose handler for performing cached game actions
-func (cl *GameClient[GT, G]) CachedHandler(action ActionFunc[GT, G]) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+// CachedHandler provides a general purpose handler for performing cached game actions.
+// Conflicting changes to the game are handled per the policy, if provided, otherwise per the policy of the client.
+// Retried requests having an Idempotency-Key are replayed the response to the first request.
+func (cl *GameClient[GT, G]) CachedHandler(action ActionFunc[GT, G], policy ...ConflictPolicy) gin.HandlerFunc {
+	return cl.idempotent(func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
 		defer Debugf(ctx, msgExit)
 
@@ -31,37 +57,41 @@ func (cl *GameClient[GT, G]) CachedHandler(action ActionFunc[GT, G]) gin.Handler
 			return
 		}
 
-		g, uid, err := cl.getGame(ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+		var (
+			g      G
+			uid    UID
+			m      logMark
+			result Result
+		)
+		if err := withRetry(ctx, cl.conflictPolicyFor(policy), func() error {
+			var err error
+			if g, uid, err = cl.getGame(ctx, cu); err != nil {
+				return err
+			}
+			v := versionOf(g, uid)
+			m = g.gameLog().mark()
 
-		result, err := action(g, ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
-		g.stack().update()
+			if result, err = action(g, ctx, cu); err != nil {
+				return err
+			}
+			g.stack().update()
 
-		g.header().UpdatedAt = timestamppb.Now()
-		if err := cl.cacheRev(ctx, g, uid); err != nil {
+			g.header().UpdatedAt = timestamppb.Now()
+			return cl.cacheRev(ctx, g, uid, v)
+		}); err != nil {
 			JErr(ctx, err)
 			return
 		}
 
-		if len(result.Message) > 0 {
-			ctx.JSON(http.StatusOK, gin.H{"Message": strings.TrimSpace(result.Message)})
-			return
-		}
-
-		ctx.JSON(http.StatusOK, nil)
-	}
+		ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, m, result.Message))
+	})
 }
 
-// CommitHandler provides a general purpose handler for performing saved game actions
-func (cl *GameClient[GT, G]) CommitHandler(action ActionFunc[GT, G]) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+// CommitHandler provides a general purpose handler for performing saved game actions.
+// Conflicting changes to the game are handled per the policy, if provided, otherwise per the policy of the client.
+// Retried requests having an Idempotency-Key are replayed the response to the first request.
+func (cl *GameClient[GT, G]) CommitHandler(action ActionFunc[GT, G], policy ...ConflictPolicy) gin.HandlerFunc {
+	return cl.idempotent(func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
 		defer Debugf(ctx, msgExit)
 
@@ -71,30 +101,33 @@ func (cl *GameClient[GT, G]) CommitHandler(action ActionFunc[GT, G]) gin.Handler
 			return
 		}
 
-		g, uid, err := cl.getGame(ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+		var (
+			g      G
+			uid    UID
+			m      logMark
+			result Result
+		)
+		if err := withRetry(ctx, cl.conflictPolicyFor(policy), func() error {
+			var err error
+			if g, uid, err = cl.getGame(ctx, cu); err != nil {
+				return err
+			}
+			v := versionOf(g, uid)
+			m = g.gameLog().mark()
 
-		result, err := action(g, ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
-		g.stack().update()
+			if result, err = action(g, ctx, cu); err != nil {
+				return err
+			}
+			g.stack().update()
 
-		if err := cl.commit(ctx, g, uid); err != nil {
+			return cl.commit(ctx, g, uid, v)
+		}); err != nil {
 			JErr(ctx, err)
 			return
 		}
 
-		if len(result.Message) > 0 {
-			ctx.JSON(http.StatusOK, H{"Message": result.Message})
-		}
-
-		ctx.JSON(http.StatusOK, nil)
-	}
+		ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, m, result.Message))
+	})
 }
 
 // FinishResult provides a return value associated with performing a finish turn action
@@ -109,9 +142,11 @@ type FinishResult struct {
 // FinishTurnActionFunc provides a func type for finish turn action executed by FinishTurnHandler
 type FinishTurnActionFunc[GT any, G Gamer[GT]] func(G, *gin.Context, *User) (FinishResult, error)
 
-// FinishTurnHandler provides a general purpose handler for performing finish turn actions
-func (cl *GameClient[GT, G]) FinishTurnHandler(action FinishTurnActionFunc[GT, G]) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+// FinishTurnHandler provides a general purpose handler for performing finish turn actions.
+// Conflicting changes to the game are handled per the policy, if provided, otherwise per the policy of the client.
+// Retried requests having an Idempotency-Key are replayed the response to the first request.
+func (cl *GameClient[GT, G]) FinishTurnHandler(action FinishTurnActionFunc[GT, G], policy ...ConflictPolicy) gin.HandlerFunc {
+	return cl.idempotent(func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
 		defer Debugf(ctx, msgExit)
 
@@ -121,57 +156,46 @@ func (cl *GameClient[GT, G]) FinishTurnHandler(action FinishTurnActionFunc[GT, G
 			return
 		}
 
-		g, uid, err := cl.getGame(ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+		var (
+			g      G
+			uid    UID
+			m      logMark
+			result FinishResult
+			ended  bool
+		)
+		if err := withRetry(ctx, cl.conflictPolicyFor(policy), func() error {
+			var err error
+			if g, uid, err = cl.getGame(ctx, cu); err != nil {
+				return err
+			}
+			v := versionOf(g, uid)
+			m = g.gameLog().mark()
 
-		result, err := action(g, ctx, cu)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+			if result, err = action(g, ctx, cu); err != nil {
+				return err
+			}
 
-		g.updateStatsFor(result.CurrentPlayerID)
+			g.updateStatsFor(result.CurrentPlayerID)
 
-		if len(result.NextPlayerIDS) == 0 {
-			if err := cl.endGame(ctx, g, uid); err != nil {
-				JErr(ctx, err)
-				return
+			if ended = len(result.NextPlayerIDS) == 0; ended {
+				return cl.endGame(ctx, g, uid, v)
 			}
-			ctx.JSON(http.StatusOK, nil)
-			return
-		}
-		notify := g.SetCurrentPlayers(result.NextPlayerIDS...)
+			notify := g.SetCurrentPlayers(result.NextPlayerIDS...)
 
-		err = cl.commit(ctx, g, uid)
-		if err != nil {
+			return cl.commit(ctx, g, uid, v, turnNotification(g, notify))
+		}); err != nil {
 			JErr(ctx, err)
 			return
 		}
 
-		go func() {
+		if !ended {
 			if err := cl.updateSubs(ctx, g.id(), result.Token, cu.ID); err != nil {
 				Warnf(ctx, "attempted to update sub: %q: %v", result.Token, err)
 			}
-
-			response, err := cl.sendNotifications(ctx, g, notify)
-			if err != nil {
-				Warnf(ctx, "attempted to send notifications to: %v: %v", result.NextPlayerIDS, err)
-			}
-			Warnf(ctx, "batch send response: %v", response)
-		}()
-
-		if len(result.Message) > 0 {
-			ctx.JSON(http.StatusOK, gin.H{
-				"Message": result.Message,
-				"Game":    g.ViewFor(cu.ID),
-			})
-			return
 		}
-		ctx.JSON(http.StatusOK, gin.H{"Game": g.ViewFor(cu.ID)})
-	}
+
+		ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, m, result.Message))
+	})
 }
 
 func (cl *GameClient[GT, G]) resetHandler() gin.HandlerFunc {
@@ -186,6 +210,8 @@ func (cl *GameClient[GT, G]) redoHandler() gin.HandlerFunc {
 	return cl.stackHandler((*Stack).redo)
 }
 
+// stackHandler updates the stack of the current user and returns the game at the updated stack.
+// As the update may remove entries of the game log, the response provides the whole game log.
 func (cl *GameClient[GT, G]) stackHandler(update func(*Stack) bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
@@ -214,20 +240,21 @@ func (cl *GameClient[GT, G]) stackHandler(update func(*Stack) bool) gin.HandlerF
 			return
 		}
 
-		// do nothing if stack does not change
-		if !update(stack) {
-			ctx.JSON(http.StatusOK, nil)
-			return
-		}
-
+		changed := update(stack)
 		g, err := cl.getGameWithStack(ctx, gid, uid, stack)
 		if err != nil {
 			JErr(ctx, err)
 			return
 		}
+
+		// do nothing if stack does not change
+		if !changed {
+			ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, logMark{}, ""))
+			return
+		}
 		g.header().UpdatedAt = timestamppb.Now()
 
-		if err := cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
 			if err := cl.txUpdateViews(ctx, tx, g, uid); err != nil {
 				return err
 			}
@@ -236,8 +263,9 @@ func (cl *GameClient[GT, G]) stackHandler(update func(*Stack) bool) gin.HandlerF
 			JErr(ctx, err)
 			return
 		}
+		cl.broker.publish(gameEvent{Kind: StackEvent, GID: gid, UID: uid})
 
-		ctx.JSON(http.StatusOK, nil)
+		ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, logMark{}, ""))
 	}
 }
 
@@ -265,11 +293,29 @@ func (cl *GameClient[GT, G]) abandonHandler(ctx *gin.Context) {
 	}
 
 	g.header().Status = Abandoned
-	if err := cl.save(ctx, g, cu.ID); err != nil {
+	var started []G
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		t, err := cl.txGetTournamentOf(tx, g.header())
+		if err != nil {
+			return err
+		}
+
+		if err := cl.txSave(ctx, tx, g, cu.ID); err != nil {
+			return err
+		}
+
+		started, err = cl.txRecordTournamentGame(ctx, tx, g.header(), t)
+		return err
+	}); err != nil {
 		JErr(ctx, err)
 		return
 	}
 
+	cl.broker.publish(gameEvent{Kind: CommitEvent, GID: g.id(), UID: cu.ID})
+	if len(started) > 0 {
+		cl.kickOutbox()
+	}
+
 	msg := fmt.Sprintf("%s has been abandoned.", g.header().Title)
 	ctx.JSON(http.StatusOK, H{"Message": msg})
 }
@@ -347,18 +393,19 @@ func (cl *GameClient[GT, G]) rollHandler(update func(*Stack, Rev) bool) gin.Hand
 			return
 		}
 
-		// do nothing if stack does not change
-		if !update(stack, obj.Rev) {
-			ctx.JSON(http.StatusOK, nil)
-			return
-		}
-
+		changed := update(stack, obj.Rev)
 		g, err := cl.getGameWithStack(ctx, gid, cu.ID, stack)
 		if err != nil {
 			JErr(ctx, err)
 			return
 		}
 
+		// do nothing if stack does not change
+		if !changed {
+			ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, logMark{}, ""))
+			return
+		}
+
 		g.header().UpdatedAt = timestamppb.Now()
 
 		err = cl.save(ctx, g, uid)
@@ -367,6 +414,6 @@ func (cl *GameClient[GT, G]) rollHandler(update func(*Stack, Rev) bool) gin.Hand
 			return
 		}
 
-		ctx.JSON(http.StatusOK, nil)
+		ctx.JSON(http.StatusOK, cl.actionResponse(g, uid, logMark{}, ""))
 	}
 }
@@ -1,19 +1,17 @@
 package sn
 
 import (
-	"bytes"
 	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
-	"html/template"
+	"math/rand/v2"
 	"slices"
 	"strconv"
 	"time"
 
 	"github.com/elliotchance/pie/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/mailjet/mailjet-apiv3-go"
 )
 
 // Game implements a game
@@ -22,6 +20,9 @@ type Game[S, PT any, P playerer[PT]] struct {
 	Players Players[PT, P]
 	Log     glog
 	State   S
+
+	rng    *rand.Rand
+	rngRev Rev
 }
 
 // Gamer interface implemented by Game
@@ -37,11 +38,12 @@ type Gamer[G any] interface {
 	setStack(*Stack)
 	toIndex() *index
 	newEntry(string, H)
+	gameLog() glog
 	playerStats() []*Stats
 	playerUIDS() []UID
 	ptr[G]
-	getResults(context.Context, []elo, []elo) results
-	sendEndGameNotifications(context.Context, results) error
+	getResults(context.Context, []Rating, []Rating) results
+	endedNotification(results) notification
 	setCurrentPlayerers
 	setFinishOrder(compareFunc) (placesMap, placesSMap)
 	starter
@@ -176,7 +178,7 @@ func (g *Game[S, T, P]) sortPlayers(compare func(PID, PID) int) {
 // RandomizePlayers randomizes the order of the players in the Players slice, and
 // updates the order of such players in the Header for the game.
 func (g *Game[S, T, P]) RandomizePlayers() {
-	g.Players.Randomize()
+	g.Players.RandomizeWith(g.Rand())
 	g.UpdateOrder()
 }
 
@@ -220,9 +222,18 @@ func (g *Game[S, T, P]) CurrentPlayerFor(ctx context.Context, u *User) (P, bool)
 // SetCurrentPlayers sets the current players to those associated with provided player ids.
 // SetCurrentPlayers also returns player ids of player that were not already a current player.
 // The returned player ids is helpful for determining to whom turn notifications should be sent
+// SetCurrentPlayers also restarts the turn limit clock, if a player becomes a current player,
+// and stops the clock, if no player remains a current player.
 func (g *Game[S, T, P]) SetCurrentPlayers(pids ...PID) []PID {
 	added, _ := pie.Diff(g.Header.CPIDS, pids)
 	g.Header.CPIDS = slices.Clone(pids)
+
+	switch {
+	case len(pids) == 0:
+		g.Header.stopTurnClock()
+	case len(added) > 0:
+		g.Header.startTurnClock()
+	}
 	return added
 }
 
@@ -379,6 +390,7 @@ func (g *Game[S, T, P]) setFinishOrder(compare compareFunc) (placesMap, placesSM
 
 type result struct {
 	PID    PID
+	Name   string
 	Place  int
 	Rating int
 	Score  int64
@@ -392,7 +404,7 @@ func (g *Game[S, T, P]) UpdateOrder() {
 
 type results []result
 
-func (g *Game[S, T, P]) getResults(ctx context.Context, oldElos, newElos []elo) results {
+func (g *Game[S, T, P]) getResults(ctx context.Context, oldRatings, newRatings []Rating) results {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
@@ -402,75 +414,23 @@ func (g *Game[S, T, P]) getResults(ctx context.Context, oldElos, newElos []elo)
 		i := int(p.PID().ToUIndex())
 		rs[i] = result{
 			PID:    p.PID(),
+			Name:   g.Header.NameFor(p.PID()),
 			Place:  p.getStats().Finish,
-			Rating: newElos[i].Rating,
+			Rating: newRatings[i].Rating,
 			Score:  p.getStats().Score,
-			Inc:    fmt.Sprintf("%+d", newElos[i].Rating-oldElos[i].Rating),
+			Inc:    fmt.Sprintf("%+d", newRatings[i].Rating-oldRatings[i].Rating),
 		}
 	}
 	return rs
 }
 
-func (g *Game[S, T, P]) sendEndGameNotifications(ctx context.Context, rs results) error {
-	Debugf(ctx, msgEnter)
-	defer Debugf(ctx, msgExit)
-
-	buf := new(bytes.Buffer)
-	tmpl, err := endGameTemplate()
-	if err != nil {
-		return err
-	}
-
-	err = tmpl.Execute(buf, gin.H{
+// endedNotification returns a notification informing players of the results of the game
+func (g *Game[S, T, P]) endedNotification(rs results) notification {
+	return eventNotification(GameEndedEvent, g.Header.Type, g.id(), recipientsFor(&g.Header, g.Header.allPIDS()), H{
+		"Game":    g.Header.Title,
 		"Results": rs,
-		"Winners": toSentence(g.winnerNames()),
+		"Winners": g.winnerNames(),
 	})
-	if err != nil {
-		return err
-	}
-
-	ms := make([]mailjet.InfoMessagesV31, len(g.Players))
-	subject := fmt.Sprintf("SlothNinja Games: (%s) Has Ended", g.Header.ID)
-	body := buf.String()
-	for i, p := range g.Players {
-		ms[i] = mailjet.InfoMessagesV31{
-			From: &mailjet.RecipientV31{
-				Email: "webmaster@slothninja.com",
-				Name:  "Webmaster",
-			},
-			To: &mailjet.RecipientsV31{
-				mailjet.RecipientV31{
-					Email: g.Header.EmailFor(p.PID()),
-					Name:  g.Header.NameFor(p.PID()),
-				},
-			},
-			Subject:  subject,
-			HTMLPart: body,
-		}
-	}
-	_, err = SendMessages(ctx, ms...)
-	return err
-}
-
-func endGameTemplate() (*template.Template, error) {
-	return template.New("end_game_notification").Parse(`
-<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">
-<html>
-        <head>
-                <meta http-equiv="content-type" content="text/html; charset=ISO-8859-1">
-        </head>
-        <body bgcolor="#ffffff" text="#000000">
-                {{range $i, $r := $.Results}}
-                <div style="height:3em">
-                        <div style="height:3em;float:left;padding-right:1em">{{$r.Place}}.</div>
-                        <div style="height:1em">{{$r.Name}} scored {{$r.Score}} points.</div>
-                        <div style="height:1em">Elo {{$r.Inc}} (-> {{$r.Rating}})</div>
-                </div>
-                {{end}}
-                <p></p>
-                <p>Congratulations: {{$.Winners}}.</p>
-        </body>
-</html>`)
 }
 
 func (g *Game[S, T, P]) winnerNames() []string {
@@ -494,6 +454,9 @@ func (g *Game[S, T, P]) newPlayer(i int) P {
 func (g *Game[S, T, P]) Start(h Header) PID {
 	g.Header = h
 	g.Header.Status = Running
+	if g.Header.Seed == 0 {
+		g.Header.Seed = NewSeed()
+	}
 
 	g.addNewPlayers()
 
@@ -554,8 +517,11 @@ func (g *Game[S, T, P]) setStack(s *Stack) {
 }
 
 func (g *Game[S, T, P]) toIndex() *index {
-	return &index{
+	i := &index{
 		Header: *(g.header()),
 		Rev:    g.stack().Committed,
 	}
+	i.Seed = 0
+	i.CPUserIDS = pie.Map(i.CPIDS, i.UIDFor)
+	return i
 }
@@ -10,6 +10,11 @@ func (cl *GameClient[GT, G]) addRoutes(prefix string) *GameClient[GT, G] {
 	// Update God Mode
 	cl.Router.PUT(cl.prefix+"/user/update-god-mode", cl.updateGodModeHandler())
 
+	/////////////////////////////////////////////
+	// Notification Preferences
+	cl.Router.GET(cl.prefix+"/user/preferences", cl.preferencesHandler())
+	cl.Router.PUT(cl.prefix+"/user/preferences", cl.updatePreferencesHandler())
+
 	////////////////////////////////////////////
 	// Invitation Group
 	iGroup := cl.Router.Group(prefix + "/invitation")
@@ -29,9 +34,25 @@ func (cl *GameClient[GT, G]) addRoutes(prefix string) *GameClient[GT, G] {
 	// Details
 	iGroup.GET("/details/:id", cl.detailsHandler())
 
+	// List
+	iGroup.GET("/list", cl.invitationsHandler())
+
 	// Abort
 	iGroup.PUT("abort/:id", cl.abortHandler())
 
+	// Edit, Cancel, Kick, and Reorder
+	iGroup.PUT("/edit/:id", cl.editInvitationHandler())
+	iGroup.PUT("/cancel/:id", cl.cancelInvitationHandler())
+	iGroup.PUT("/kick/:id", cl.kickHandler())
+	iGroup.PUT("/reorder/:id", cl.reorderHandler())
+
+	// Start with the users that have joined
+	iGroup.PUT("/start/:id", cl.startNowHandler())
+
+	// Invites
+	iGroup.GET("/inbox", cl.inboxHandler())
+	iGroup.PUT("/decline/:id", cl.declineHandler())
+
 	/////////////////////////////////////////////
 	// Game Group
 	gGroup := cl.Router.Group(prefix + "/game")
@@ -51,12 +72,85 @@ func (cl *GameClient[GT, G]) addRoutes(prefix string) *GameClient[GT, G] {
 	// Rollforward
 	gGroup.PUT("rollforward/:id", cl.rollforwardHandler())
 
+	// Legal actions, for games implementing LegalActioner
+	gGroup.GET("actions/:id", cl.legalActionsHandler())
+
+	// Dry run of an action, for games implementing DryRunner
+	gGroup.PUT("dryrun/:action/:id", cl.dryRunHandler())
+
 	// Abandon
 	gGroup.PUT("abandon/:id", cl.abandonHandler)
 
 	// Revive
 	gGroup.PUT("revive/:id", cl.reviveHandler)
 
+	// Replay
+	gGroup.GET("replay/:id", cl.replayHandler())
+
+	// Diff
+	gGroup.GET("diff/:id", cl.diffHandler())
+
+	// Spectate
+	gGroup.GET("spectate/:id", cl.spectateHandler())
+
+	// Stream
+	gGroup.GET("stream/:id", cl.streamHandler())
+
+	// List
+	gGroup.GET("list", cl.gamesHandler())
+
+	/////////////////////////////////////////////
+	// Tournament Group
+	tGroup := cl.Router.Group(prefix + "/tournament")
+
+	// Create
+	tGroup.PUT("/new", cl.createTournamentHandler())
+
+	// Join
+	tGroup.PUT("/join/:id", cl.joinTournamentHandler())
+
+	// Leave
+	tGroup.PUT("/leave/:id", cl.leaveTournamentHandler())
+
+	// Start
+	tGroup.PUT("/start/:id", cl.startTournamentHandler())
+
+	// Show
+	tGroup.GET("/show/:id", cl.showTournamentHandler())
+
+	/////////////////////////////////////////////
+	// Rating Group
+	rGroup := cl.Router.Group(prefix + "/rating")
+
+	// Leaderboard
+	rGroup.GET("/leaderboard", cl.leaderboardHandler())
+
+	// History
+	rGroup.GET("/history/:uid", cl.ratingHistoryHandler())
+
+	// Head-to-Head
+	rGroup.GET("/h2h/:uid1/:uid2", cl.headToHeadHandler())
+
+	/////////////////////////////////////////////
+	// Queue Group
+	qGroup := cl.Router.Group(prefix + "/queue")
+
+	// Join
+	qGroup.PUT("/join", cl.joinQueueHandler())
+
+	// Leave
+	qGroup.PUT("/leave", cl.leaveQueueHandler())
+
+	// Status
+	qGroup.GET("/status", cl.queueStatusHandler())
+
+	/////////////////////////////////////////////
+	// Cron
+	cl.Router.GET(cl.prefix+"/cron/deadlines", cl.deadlinesHandler())
+	cl.Router.GET(cl.prefix+"/cron/matchmaking", cl.matchmakingHandler())
+	cl.Router.GET(cl.prefix+"/cron/outbox", cl.outboxHandler())
+	cl.Router.GET(cl.prefix+"/cron/invitations", cl.expireInvitationsHandler())
+
 	/////////////////////////////////////////////
 	// Message Log
 	msg := cl.Router.Group(prefix + "/mlog")
@@ -67,5 +161,17 @@ func (cl *GameClient[GT, G]) addRoutes(prefix string) *GameClient[GT, G] {
 	// Add
 	msg.PUT("/add/:id", cl.addMessageHandler())
 
+	// Edit, Delete, and React
+	msg.PUT("/edit/:id", cl.editMessageHandler())
+	msg.PUT("/delete/:id", cl.deleteMessageHandler())
+	msg.PUT("/react/:id", cl.reactHandler())
+
+	// History
+	msg.GET("/messages/:id", cl.messagesHandler())
+
+	// Moderation
+	msg.GET("/moderation/:id", cl.moderationHandler())
+	msg.PUT("/moderate/:id", cl.moderateHandler())
+
 	return cl
 }
@@ -0,0 +1,146 @@
+package sn
+
+import (
+	"math"
+)
+
+// GlickoRater provides a Glicko-2 rating system.
+// Each game is treated as a rating period in which each player played each other player of the game.
+type GlickoRater struct {
+	// Tau constrains the change of volatility over time.
+	// If zero, defaults to 0.5
+	Tau float64
+}
+
+const (
+	glickoScale             = 173.7178
+	glickoDefaultRating     = 1500
+	glickoDefaultDeviation  = 350
+	glickoDefaultVolatility = 0.06
+	glickoDefaultTau        = 0.5
+	glickoEpsilon           = 0.000001
+)
+
+// System implements Rater interface
+func (GlickoRater) System() string {
+	return "glicko2"
+}
+
+// Initial implements Rater interface
+func (GlickoRater) Initial() Rating {
+	return Rating{
+		Rating:     glickoDefaultRating,
+		Mu:         glickoDefaultRating,
+		Deviation:  glickoDefaultDeviation,
+		Volatility: glickoDefaultVolatility,
+	}
+}
+
+// Rate implements Rater interface
+func (gr GlickoRater) Rate(ratings []Rating, places []int) []Rating {
+	tau := gr.Tau
+	if tau == 0 {
+		tau = glickoDefaultTau
+	}
+
+	rated := make([]Rating, len(ratings))
+	for i := range ratings {
+		rated[i] = glickoRate(ratings, places, i, tau)
+	}
+	return rated
+}
+
+// glickoRate returns the updated rating of player i per step 2 through 8 of the Glicko-2 algorithm
+func glickoRate(ratings []Rating, places []int, i int, tau float64) Rating {
+	r := ratings[i]
+	mu, phi, sigma := glickoMu(r), glickoPhi(r), glickoSigma(r)
+
+	var vInv, sum float64
+	for j := range ratings {
+		if i == j {
+			continue
+		}
+		muj, phij := glickoMu(ratings[j]), glickoPhi(ratings[j])
+		g := glickoG(phij)
+		e := 1 / (1 + math.Exp(-g*(mu-muj)))
+		vInv += g * g * e * (1 - e)
+		sum += g * (pairwiseScore(places[i], places[j]) - e)
+	}
+
+	// a player without opponents only has their deviation increased
+	if vInv == 0 {
+		r.Deviation = math.Sqrt(phi*phi+sigma*sigma) * glickoScale
+		return r
+	}
+
+	v := 1 / vInv
+	delta := v * sum
+	sigma = glickoVolatility(delta, phi, v, sigma, tau)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+
+	r.Mu = glickoScale*mu + glickoDefaultRating
+	r.Rating = int(math.Round(r.Mu))
+	r.Deviation = glickoScale * phi
+	r.Volatility = sigma
+	return r
+}
+
+// glickoVolatility returns the new volatility per step 5 of the Glicko-2 algorithm (Illinois algorithm)
+func glickoVolatility(delta, phi, v, sigma, tau float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEpsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoMu(r Rating) float64 {
+	return (r.Mu - glickoDefaultRating) / glickoScale
+}
+
+func glickoPhi(r Rating) float64 {
+	if r.Deviation == 0 {
+		return glickoDefaultDeviation / glickoScale
+	}
+	return r.Deviation / glickoScale
+}
+
+func glickoSigma(r Rating) float64 {
+	if r.Volatility == 0 {
+		return glickoDefaultVolatility
+	}
+	return r.Volatility
+}
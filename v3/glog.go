@@ -127,3 +127,30 @@ func (g *Game[S, T, P]) lastSubEntryIndex() int {
 func (g *Game[S, T, P]) lastSubEntry() *subentry {
 	return g.lastSubEntries()[g.lastSubEntryIndex()]
 }
+
+func (g *Game[S, T, P]) gameLog() glog {
+	return g.Log
+}
+
+// logMark marks the end of a game log, permitting the entries added or updated after the mark to be found
+type logMark struct {
+	n         int
+	updatedAt *timestamppb.Timestamp
+}
+
+func (l glog) mark() logMark {
+	if len(l) == 0 {
+		return logMark{}
+	}
+	return logMark{n: len(l), updatedAt: l[len(l)-1].UpdatedAt}
+}
+
+// since returns the offset and entries of the log added or updated after the mark.
+// A zero mark returns the whole log.
+func (l glog) since(m logMark) (int, glog) {
+	offset := min(m.n, len(l))
+	if offset > 0 && l[offset-1].UpdatedAt != m.updatedAt {
+		offset--
+	}
+	return offset, l[offset:]
+}
@@ -1,6 +1,8 @@
 package sn
 
 import (
+	"time"
+
 	"github.com/elliotchance/pie/v2"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -28,6 +30,7 @@ type Header struct {
 	UserEmails                []string
 	UserEmailHashes           []string
 	UserEmailNotifications    []bool
+	UserEmailReminders        []bool
 	UserGravTypes             []string
 	OrderIDS                  []PID
 	CPIDS                     []PID
@@ -40,7 +43,39 @@ type Header struct {
 	EndedAt                   *timestamppb.Timestamp
 	CreatedAt                 *timestamppb.Timestamp
 	UpdatedAt                 *timestamppb.Timestamp
-	Private                   bool
+	// ExpiresAt provides the time at which an invitation that has not filled expires and is removed
+	ExpiresAt *timestamppb.Timestamp
+	// InviteeIDS, InviteeNames, and InviteeEmails provide the users invited directly to an invitation,
+	// for whom seats are reserved until they accept or decline
+	InviteeIDS                []UID
+	InviteeNames              []string
+	InviteeEmails             []string
+	InviteeEmailNotifications []bool
+	// CancelOnDecline indicates an invitation is cancelled, rather than the seat of the invitee opened, when an invitee declines
+	CancelOnDecline bool
+	Private         bool
+	// Seed from which the randomness of the game is derived.
+	// Seed is removed from views and the index, so users can not predict random outcomes.
+	Seed int64
+	// TurnLimit provides the amount of time a player has to finish a turn.
+	// A zero TurnLimit indicates no limit.
+	TurnLimit time.Duration
+	// TimeoutAction provides the action taken when a player exceeds the TurnLimit
+	TimeoutAction TimeoutAction
+	// TurnDeadline provides the time by which current players must finish their turn
+	TurnDeadline *timestamppb.Timestamp
+	// TurnReminders provides the number of reminders sent for the current turn
+	TurnReminders int
+	// TurnCheckAt provides the time at which the next reminder or timeout is due
+	TurnCheckAt *timestamppb.Timestamp
+	// DroppedIDS provides the user ids of players dropped for exceeding the TurnLimit
+	DroppedIDS []UID
+	// AllowSpectators indicates whether users that are not players may view the running game
+	AllowSpectators bool
+	// SpectatorDelay provides the number of revisions by which spectator views of the running game are delayed
+	SpectatorDelay int
+	// TournamentID provides the id of the tournament to which the game belongs, if any
+	TournamentID string
 }
 
 func (h *Header) users() []*User {
@@ -53,6 +88,7 @@ func (h *Header) users() []*User {
 				Email:              h.UserEmails[i],
 				EmailHash:          h.UserEmailHashes[i],
 				EmailNotifications: h.UserEmailNotifications[i],
+				EmailReminders:     h.emailRemindersAt(i),
 				GravType:           h.UserGravTypes[i],
 			},
 		}
@@ -73,6 +109,28 @@ func (h Header) EmailFor(pid PID) string {
 	return h.UserEmails[pid.ToUIndex()]
 }
 
+// EmailRemindersFor returns whether email reminders are to be sent for the player associated with the player id
+func (h Header) EmailRemindersFor(pid PID) bool {
+	return h.emailRemindersAt(int(pid.ToUIndex()))
+}
+
+// emailRemindersAt returns whether email reminders are to be sent for the user at index i.
+// Headers created prior to the introduction of UserEmailReminders default to false.
+func (h Header) emailRemindersAt(i int) bool {
+	if i < 0 || i >= len(h.UserEmailReminders) {
+		return false
+	}
+	return h.UserEmailReminders[i]
+}
+
+// padEmailReminders pads the UserEmailReminders of headers created prior to its introduction,
+// so the reminders of users added or removed remain aligned with their user ids.
+func (h *Header) padEmailReminders() {
+	if n := len(h.UserIDS) - len(h.UserEmailReminders); n > 0 {
+		h.UserEmailReminders = append(h.UserEmailReminders, make([]bool, n)...)
+	}
+}
+
 // EmailNotificationsFor returns whether email notifications are to be sent for the player associated with the player id
 func (h Header) EmailNotificationsFor(pid PID) bool {
 	return h.UserEmailNotifications[pid.ToUIndex()]
@@ -109,6 +167,15 @@ func (h Header) IndexFor(uid UID) (index UIndex, found bool) {
 	return index, true
 }
 
+// allPIDS returns the player ids associated with the users of the header
+func (h Header) allPIDS() []PID {
+	pids := make([]PID, len(h.UserIDS))
+	for i := range pids {
+		pids[i] = UIndex(i).ToPID()
+	}
+	return pids
+}
+
 // NameFor returns the user name for the player associated with the player id
 func (h Header) NameFor(pid PID) string {
 	return h.UserNames[pid.ToUIndex()]
@@ -0,0 +1,181 @@
+package sn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader provides the header via which clients identify retries of a request
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// idempotentReplayedHeader marks responses replayed from an idempotency record
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyTTL provides the time during which responses are replayed to retried requests
+	idempotencyTTL = 24 * time.Hour
+)
+
+// idempotencyRecord provides the first response to a request having an idempotency key.
+// Pending records reserve the key while the first request is in flight.
+// ExpiresAt permits the store to remove expired records, e.g., via a Firestore TTL policy.
+type idempotencyRecord struct {
+	UID         UID
+	RequestHash string
+	Pending     bool
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// responseRecorder records the response written by a handler, while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// idempotent returns a handler performing handler at most once per game, user, and Idempotency-Key.
+// The key is reserved before performing handler, and retries arriving while the key is reserved are rejected as conflicts.
+// The response of the first successful request is recorded and replayed to retries of the request,
+// and requests reusing a key with a different method, path, or body are rejected.
+// Keys of failed requests are released, so the request may be retried.
+// Should the service stop while performing handler, the key remains reserved until the record expires,
+// as the changes of the request may have been saved.
+// Requests without an Idempotency-Key are simply handled.
+func (cl *GameClient[GT, G]) idempotent(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			handler(ctx)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			JErr(ctx, fmt.Errorf("%s may not exceed %d characters: %w",
+				IdempotencyKeyHeader, maxIdempotencyKeyLength, ErrValidation))
+			return
+		}
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var body []byte
+		if ctx.Request.Body != nil {
+			if body, err = io.ReadAll(ctx.Request.Body); err != nil {
+				JErr(ctx, err)
+				return
+			}
+			ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		gid, id, hash := getID(ctx), idempotencyID(cu.ID, key), requestHash(ctx.Request, body)
+		now := time.Now()
+
+		var (
+			rec    idempotencyRecord
+			replay bool
+		)
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			rec, replay = idempotencyRecord{}, false
+			switch err := tx.GetIdempotencyRecord(gid, id, &rec); {
+			case errors.Is(err, ErrNotFound):
+			case err != nil:
+				return err
+			case now.After(rec.ExpiresAt):
+			case rec.RequestHash != hash:
+				return fmt.Errorf("%s %q was used with a different request: %w", IdempotencyKeyHeader, key, ErrValidation)
+			case rec.Pending:
+				return fmt.Errorf("request having %s %q is in progress: %w", IdempotencyKeyHeader, key, ErrConflict)
+			default:
+				replay = true
+				return nil
+			}
+
+			return tx.SetIdempotencyRecord(gid, id, idempotencyRecord{
+				UID:         cu.ID,
+				RequestHash: hash,
+				Pending:     true,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(idempotencyTTL),
+			})
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if replay {
+			ctx.Header(idempotentReplayedHeader, "true")
+			ctx.Data(rec.Status, rec.ContentType, rec.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer, body: new(bytes.Buffer)}
+		ctx.Writer = recorder
+		handler(ctx)
+
+		// Failed requests release the key, so they may be retried
+		if len(ctx.Errors) > 0 || recorder.Status() != http.StatusOK {
+			if err := cl.store.DeleteIdempotencyRecord(ctx, gid, id); err != nil {
+				Warnf(ctx, "unable to release %s %q: %v", IdempotencyKeyHeader, key, err)
+			}
+			return
+		}
+
+		rec = idempotencyRecord{
+			UID:         cu.ID,
+			RequestHash: hash,
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyTTL),
+		}
+		if err := cl.store.SetIdempotencyRecord(ctx, gid, id, rec); err != nil {
+			Warnf(ctx, "unable to record response for %s %q: %v", IdempotencyKeyHeader, key, err)
+		}
+	}
+}
+
+// idempotencyID returns the id of the idempotency record of the user and key.
+// Keys are hashed, as keys are provided by clients and need not be valid document ids.
+func idempotencyID(uid UID, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return uid.toString() + "-" + hex.EncodeToString(sum[:])
+}
+
+// requestHash returns a hash of the method, path, and body of the request
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingHandler returns a commit handler counting its calls and the count given by the request body.
+// The first fail calls return a validation error.
+func countingHandler(cl *testClient, calls *int, fail int) gin.HandlerFunc {
+	return cl.CommitHandler(func(g *testGame, ctx *gin.Context, _ *User) (Result, error) {
+		*calls++
+		if *calls <= fail {
+			return Result{}, fmt.Errorf("failed call %d: %w", *calls, ErrValidation)
+		}
+
+		var obj struct{ By int }
+		if err := ctx.ShouldBindJSON(&obj); err != nil {
+			return Result{}, err
+		}
+		g.State.Count += obj.By
+		return Result{}, nil
+	})
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", countingHandler(cl, &calls, 0))
+
+	first := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":2}`, IdempotencyKeyHeader, "key-1")
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", first.Code, http.StatusOK, first.Body)
+	}
+
+	retry := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":2}`, IdempotencyKeyHeader, "key-1")
+	if retry.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", retry.Code, http.StatusOK, retry.Body)
+	}
+
+	if got := retry.Header().Get(idempotentReplayedHeader); got != "true" {
+		t.Errorf("%s = %q, want \"true\"", idempotentReplayedHeader, got)
+	}
+
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("replayed body = %s, want %s", retry.Body, first.Body)
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	if i := getTestIndex(t, cl); i.Rev != 1 {
+		t.Errorf("index Rev = %d, want 1", i.Rev)
+	}
+
+	// a new key performs the action again
+	if w := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":2}`, IdempotencyKeyHeader, "key-2"); w.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("new key replayed: %s", w.Body)
+	}
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestIdempotentKeysArePerUser(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", countingHandler(cl, &calls, 0))
+
+	for _, uid := range []UID{1, 2} {
+		w := serve(cl, http.MethodPut, "/game/"+testGID, uid, `{"By":1}`, IdempotencyKeyHeader, "key-1")
+		if w.Header().Get(idempotentReplayedHeader) != "" {
+			t.Errorf("user %d replayed: %s", uid, w.Body)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestIdempotentRejectsReusedKey(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", countingHandler(cl, &calls, 0))
+
+	serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":1}`, IdempotencyKeyHeader, "key-1")
+
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":5}`, IdempotencyKeyHeader, "key-1", ErrorFormatHeader, "structured")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestIdempotentRejectsInFlightRetry(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", countingHandler(cl, &calls, 0))
+
+	// reserve the key, as if the first request is being performed
+	const body = `{"By":1}`
+	req := httptest.NewRequest(http.MethodPut, "/game/"+testGID, strings.NewReader(body))
+	now := time.Now()
+	if err := cl.store.SetIdempotencyRecord(context.Background(), testGID, idempotencyID(1, "key-1"), idempotencyRecord{
+		UID:         1,
+		RequestHash: requestHash(req, []byte(body)),
+		Pending:     true,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyTTL),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(cl, http.MethodPut, "/game/"+testGID, 1, body, IdempotencyKeyHeader, "key-1", ErrorFormatHeader, "structured")
+	if w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body)
+	}
+
+	if calls != 0 {
+		t.Errorf("calls = %d, want 0", calls)
+	}
+}
+
+func TestIdempotentReleasesKeyOfFailedRequest(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	var calls int
+	cl.Router.PUT("/game/:id", countingHandler(cl, &calls, 1))
+
+	failed := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":1}`, IdempotencyKeyHeader, "key-1", ErrorFormatHeader, "structured")
+	if failed.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", failed.Code, http.StatusBadRequest, failed.Body)
+	}
+
+	var rec idempotencyRecord
+	err := cl.store.RunTransaction(context.Background(), func(_ context.Context, tx Tx) error {
+		return tx.GetIdempotencyRecord(testGID, idempotencyID(1, "key-1"), &rec)
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetIdempotencyRecord = %v, want ErrNotFound", err)
+	}
+
+	retry := serve(cl, http.MethodPut, "/game/"+testGID, 1, `{"By":1}`, IdempotencyKeyHeader, "key-1")
+	if retry.Code != http.StatusOK || retry.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("retry status = %d, replayed = %q: %s", retry.Code, retry.Header().Get(idempotentReplayedHeader), retry.Body)
+	}
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
@@ -1,7 +1,171 @@
 package sn
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultGamesLimit = 25
+	maxGamesLimit     = 100
+)
+
 // index used to index games based on header and rev
 type index struct {
 	Header
 	Rev Rev
+	// CPUserIDS provides the user ids of the current players, so games may be queried by whose turn it is
+	CPUserIDS []UID
+}
+
+// public returns a copy of the index with the email addresses of users removed
+func (i index) public() index {
+	i.CreatorEmail = ""
+	i.UserEmails = make([]string, len(i.UserEmails))
+	return i
+}
+
+// gameStatuses provides the statuses by which games may be listed
+var gameStatuses = []Status{Running, Completed, Abandoned}
+
+// gamesHandler returns a page of indexed games ordered by UpdatedAt.
+// Query parameters:
+//   - status: one of running (default), completed, or abandoned
+//   - type: limits games to a game type
+//   - uid: limits games to those having the user as a player
+//   - turn: true limits games to those in which it is the turn of the current user; may not be combined with uid
+//   - sort: desc (default) lists the most recently updated games first, asc the least recently updated
+//   - cursor, limit: provide pagination, where cursor is the Cursor returned with the prior page
+//
+// Requires composite indices on the Status, Type, UserIDS, CPUserIDS, and UpdatedAt fields of the Index collection.
+func (cl *GameClient[GT, G]) gamesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		q, err := getIndexQuery(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if ctx.Query("turn") == "true" {
+			if q.UserID != 0 {
+				JErr(ctx, fmt.Errorf("uid and turn may not be combined: %w", ErrValidation))
+				return
+			}
+
+			cu, err := cl.RequireLogin(ctx)
+			if err != nil {
+				JErr(ctx, err)
+				return
+			}
+			q.CurrentUserID = cu.ID
+		}
+
+		is, err := cl.listIndexes(ctx, q)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var cursor string
+		if len(is) == q.Limit {
+			last := is[len(is)-1]
+			cursor = encodeCursor(IndexCursor{UpdatedAt: last.UpdatedAt.AsTime(), ID: last.id()})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Games": is, "Cursor": cursor})
+	}
+}
+
+// getIndexQuery returns a query for indexed games, ordered by UpdatedAt, per the query parameters
+func getIndexQuery(ctx *gin.Context) (IndexQuery, error) {
+	q := IndexQuery{
+		Status:          Status(ctx.DefaultQuery("status", string(Running))),
+		Type:            Type(ctx.Query("type")),
+		SortByUpdatedAt: true,
+	}
+
+	if !slices.Contains(gameStatuses, q.Status) {
+		return IndexQuery{}, fmt.Errorf("unknown status %q: %w", q.Status, ErrValidation)
+	}
+
+	switch s := ctx.DefaultQuery("sort", "desc"); s {
+	case "desc":
+		q.Desc = true
+	case "asc":
+	default:
+		return IndexQuery{}, fmt.Errorf("unknown sort %q: %w", s, ErrValidation)
+	}
+
+	uid, err := getIntQuery(ctx, "uid", 0)
+	if err != nil {
+		return IndexQuery{}, err
+	}
+	q.UserID = UID(uid)
+
+	if s := ctx.Query("cursor"); s != "" {
+		c, err := decodeCursor(s)
+		if err != nil {
+			return IndexQuery{}, err
+		}
+		q.After = &c
+	}
+
+	if q.Limit, err = getIntQuery(ctx, "limit", defaultGamesLimit); err != nil {
+		return IndexQuery{}, err
+	}
+
+	if q.Limit < 1 || q.Limit > maxGamesLimit {
+		return IndexQuery{}, fmt.Errorf("limit must be between 1 and %d: %w", maxGamesLimit, ErrValidation)
+	}
+	return q, nil
+}
+
+// listIndexes returns the indexed games matching the query, with the email addresses of users removed
+func (cl *GameClient[GT, G]) listIndexes(ctx *gin.Context, q IndexQuery) ([]index, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	docs, err := cl.store.ListIndexes(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	is := make([]index, len(docs))
+	for j, doc := range docs {
+		var i index
+		if err := doc.DataTo(&i); err != nil {
+			return nil, err
+		}
+		i.setID(doc.ID())
+		is[j] = i.public()
+	}
+	return is, nil
+}
+
+func encodeCursor(c IndexCursor) string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("unable to marshal cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (IndexCursor, error) {
+	var c IndexCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || c.ID == "" {
+		return IndexCursor{}, fmt.Errorf("invalid cursor %q: %w", s, ErrValidation)
+	}
+	return c, nil
 }
@@ -5,9 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"firebase.google.com/go/v4/messaging"
 	"github.com/Pallinder/go-randomdata"
 	"github.com/elliotchance/pie/v2"
 	"github.com/gin-gonic/gin"
@@ -17,16 +16,12 @@ import (
 
 type invitation struct{ Header }
 
-func (cl *GameClient[GT, G]) invitationDocRef(id string) *firestore.DocumentRef {
-	return cl.invitationCollectionRef().Doc(id)
-}
-
-func (cl *GameClient[GT, G]) invitationCollectionRef() *firestore.CollectionRef {
-	return cl.FS.Collection("Invitation")
-}
-
-func (cl *GameClient[GT, G]) hashDocRef(id string) *firestore.DocumentRef {
-	return cl.invitationDocRef(id).Collection("Hash").Doc("hash")
+// public returns the invitation less the email addresses of its creator, users, and invitees
+func (inv invitation) public() invitation {
+	inv.CreatorEmail = ""
+	inv.UserEmails = make([]string, len(inv.UserEmails))
+	inv.InviteeEmails = make([]string, len(inv.InviteeEmails))
+	return inv
 }
 
 func (cl *GameClient[GT, G]) abortHandler() gin.HandlerFunc {
@@ -49,7 +44,7 @@ func (cl *GameClient[GT, G]) abortHandler() gin.HandlerFunc {
 		now := timestamppb.Now()
 		inv.UpdatedAt = now
 		inv.EndedAt = now
-		if _, err := cl.invitationDocRef(inv.id()).Set(ctx, inv); err != nil {
+		if err := cl.store.SetInvitation(ctx, inv.id(), inv); err != nil {
 			JErr(ctx, err)
 			return
 		}
@@ -66,12 +61,17 @@ func (cl *GameClient[GT, G]) getInvitation(ctx *gin.Context) (invitation, error)
 	var inv invitation
 
 	id := getID(ctx)
-	snap, err := cl.invitationDocRef(id).Get(ctx)
-	if err != nil {
+	if err := cl.store.GetInvitation(ctx, id, &inv); err != nil {
 		return inv, err
 	}
 
-	if err = snap.DataTo(&inv); err != nil {
+	inv.setID(id)
+	return inv, nil
+}
+
+func (cl *GameClient[GT, G]) txGetInvitation(tx Tx, id string) (invitation, error) {
+	var inv invitation
+	if err := tx.GetInvitation(id, &inv); err != nil {
 		return inv, err
 	}
 
@@ -83,36 +83,21 @@ func (cl *GameClient[GT, G]) getHash(ctx context.Context, id string) ([]byte, er
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.hashDocRef(id).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	hashInf, err := snap.DataAt("Hash")
-	if err != nil {
-		return nil, err
-	}
-
-	hash, ok := hashInf.([]byte)
-	if !ok {
-		return nil, fmt.Errorf("unexpected type for stored hash")
-	}
-	return hash, nil
+	return cl.store.GetHash(ctx, id)
 }
 
 func (cl *GameClient[GT, G]) deleteInvitation(ctx context.Context, id string) error {
-	return cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+	return cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
 		return cl.txDeleteInvitation(tx, id)
 	})
 }
 
-func (cl *GameClient[GT, G]) txDeleteInvitation(tx *firestore.Transaction, id string) error {
-	ref := cl.invitationDocRef(id)
-	if err := tx.Delete(ref); err != nil {
+func (cl *GameClient[GT, G]) txDeleteInvitation(tx Tx, id string) error {
+	if err := tx.DeleteInvitation(id); err != nil {
 		return err
 	}
 
-	if err := tx.Delete(cl.hashDocRef(id)); err != nil {
+	if err := tx.DeleteHash(id); err != nil {
 		return err
 	}
 	return nil
@@ -154,29 +139,32 @@ func (cl *GameClient[GT, G]) createInvitationHandler() gin.HandlerFunc {
 		}
 
 		var inv invitation
-		inv, hash, token, err := fromForm(ctx, cu)
+		inv, hash, token, err := cl.fromForm(ctx, cu)
 		if err != nil {
 			JErr(ctx, err)
 			return
 		}
 
-		if err := cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
 			t := timestamppb.Now()
 			inv.CreatedAt, inv.UpdatedAt = t, t
-			ref := cl.invitationCollectionRef().NewDoc()
-			if err := tx.Create(ref, inv); err != nil {
+			id, err := tx.CreateInvitation(inv)
+			if err != nil {
 				return err
 			}
-			inv.setID(ref.ID)
+			inv.setID(id)
 
 			if len(hash) > 0 {
-				return tx.Create(cl.hashDocRef(inv.id()), gin.H{"Hash": hash})
+				if err := tx.CreateHash(inv.id(), hash); err != nil {
+					return err
+				}
 			}
-			return nil
+			return cl.txNotify(tx, inv.invitedNotification())
 		}); err != nil {
 			JErr(ctx, err)
 			return
 		}
+		cl.kickOutbox()
 
 		if err := cl.updateSubs(ctx, inv.id(), token, cu.ID); err != nil {
 			Warnf(ctx, "attempted to update sub: %q: %v", token, err)
@@ -191,17 +179,24 @@ func (cl *GameClient[GT, G]) createInvitationHandler() gin.HandlerFunc {
 	}
 }
 
-func fromForm(ctx *gin.Context, cu *User) (invitation, []byte, SubToken, error) {
+func (cl *Client) fromForm(ctx *gin.Context, cu *User) (invitation, []byte, SubToken, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	obj := struct {
-		Type       Type
-		Title      string
-		NumPlayers int
-		OptString  string
-		Password   string
-		Token      SubToken
+		Type            Type
+		Title           string
+		NumPlayers      int
+		OptString       string
+		Password        string
+		Token           SubToken
+		TurnLimitHours  int
+		TimeoutAction   TimeoutAction
+		AllowSpectators bool
+		SpectatorDelay  int
+		ExpiresInDays   int
+		Invitees        []invitee
+		CancelOnDecline bool
 	}{}
 
 	err := ctx.ShouldBind(&obj)
@@ -220,6 +215,18 @@ func fromForm(ctx *gin.Context, cu *User) (invitation, []byte, SubToken, error)
 	inv.OptString = obj.OptString
 	inv.Status = Recruiting
 
+	if err := inv.setTurnLimit(obj.TurnLimitHours, obj.TimeoutAction); err != nil {
+		return invitation{}, nil, "", err
+	}
+
+	if err := inv.setSpectators(obj.AllowSpectators, obj.SpectatorDelay); err != nil {
+		return invitation{}, nil, "", err
+	}
+
+	if err := inv.setExpiry(obj.ExpiresInDays, time.Now()); err != nil {
+		return invitation{}, nil, "", err
+	}
+
 	var hash []byte
 	if len(obj.Password) > 0 {
 		hash, err = bcrypt.GenerateFromPassword([]byte(obj.Password), bcrypt.DefaultCost)
@@ -230,9 +237,21 @@ func fromForm(ctx *gin.Context, cu *User) (invitation, []byte, SubToken, error)
 	}
 	inv.addCreator(cu)
 	inv.addUser(cu)
+
+	us, err := cl.getInvitees(ctx, obj.Invitees)
+	if err != nil {
+		return invitation{}, nil, "", err
+	}
+
+	if err := inv.invite(us, obj.CancelOnDecline); err != nil {
+		return invitation{}, nil, "", err
+	}
 	return inv, hash, obj.Token, nil
 }
 
+// acceptHandler adds the current user to an invitation, starting the game once the invitation fills.
+// The invitation is read and updated within a transaction, so an accept racing a decline, cancel, or kick
+// cannot restore the prior state of the invitation.
 func (cl *GameClient[GT, G]) acceptHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
@@ -250,15 +269,6 @@ func (cl *GameClient[GT, G]) acceptHandler() gin.HandlerFunc {
 			return
 		}
 
-		var hash []byte
-		if inv.Private {
-			hash, err = cl.getHash(ctx, inv.id())
-			if err != nil {
-				JErr(ctx, err)
-				return
-			}
-		}
-
 		obj := struct {
 			Password string
 			Token    SubToken
@@ -270,88 +280,125 @@ func (cl *GameClient[GT, G]) acceptHandler() gin.HandlerFunc {
 			return
 		}
 
-		start, err := inv.acceptWith(ctx, cu, []byte(obj.Password), hash)
-		if err != nil {
-			JErr(ctx, err)
-			return
+		// Compare the password outside the transaction, as hashing is slow and transactions may be retried
+		var pwdErr error
+		if inv.Private {
+			hash, err := cl.getHash(ctx, inv.id())
+			if err != nil {
+				JErr(ctx, err)
+				return
+			}
+			pwdErr = bcrypt.CompareHashAndPassword(hash, []byte(obj.Password))
 		}
 
-		if _, err := cl.FCM.SubscribeToTopic(ctx, []string{string(obj.Token)}, cu.ID.toString()); err != nil {
-			Warnf(ctx, "attempted to update sub: %q: %v", obj.Token, err)
+		// Added to the outbox in the transaction accepting the invitation, so users are only notified of joins
+		// that happened, and sent via the channels preferred by recipients whether or not FCM is configured
+		joined, err := cl.joinedNotification(ctx, cu, &inv)
+		if err != nil {
+			Warnf(ctx, "unable to create join notification for invitation %s: %v", inv.id(), err)
 		}
 
-		go func() {
-			message := &messaging.Message{
-				Topic: cu.ID.toString(),
-				Notification: &messaging.Notification{
-					Title:    "You joined game",
-					Body:     "Thanks for joining game.",
-					ImageURL: "https://tammany.slothninja.com/logo.png",
-				},
-				Webpush: &messaging.WebpushConfig{
-					FCMOptions: &messaging.WebpushFCMOptions{
-						Link: "https://www.slothninja.com",
-					},
-				},
-			}
-			name, err := cl.FCM.Send(ctx, message)
+		var msg string
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			inv, err := cl.txGetInvitation(tx, inv.id())
 			if err != nil {
-				Warnf(ctx, "attempted to send join notifications to: %v: %v", obj.Token, err)
+				return err
 			}
-			Warnf(ctx, "batch send name: %s", name)
-		}()
 
-		if !start {
-			inv.UpdatedAt = timestamppb.Now()
-			_, err = cl.invitationDocRef(inv.id()).Set(ctx, inv)
+			start, err := inv.acceptWith(ctx, cu, pwdErr)
 			if err != nil {
-				JErr(ctx, err)
-				return
+				return err
 			}
-			ctx.JSON(http.StatusOK, gin.H{"Message": inv.acceptGameMessage(cu)})
-			return
-		}
 
-		g := G(new(GT))
-		cpid, err := g.Start(ctx, inv.Header)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+			if start {
+				if msg, err = cl.txStartInvitation(ctx, tx, inv, cu); err != nil {
+					return err
+				}
+				return cl.txNotify(tx, joined)
+			}
 
-		if err := cl.FS.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
-			g.header().UpdatedAt = timestamppb.Now()
-			if err := cl.txSave(ctx, tx, g, cu.ID); err != nil {
+			inv.UpdatedAt = timestamppb.Now()
+			msg = inv.acceptGameMessage(cu)
+			if err := tx.SetInvitation(inv.id(), inv); err != nil {
 				return err
 			}
-			return cl.txDeleteInvitation(tx, inv.id())
+			return cl.txNotify(tx, joined)
 		}); err != nil {
 			JErr(ctx, err)
 			return
 		}
+		cl.kickOutbox()
 
-		go func() {
-			responses, err := cl.sendNotifications(ctx, g, g.header().CPIDS)
-			if err != nil {
-				Warnf(ctx, "attempted to send notifications to: %v: %v", g.header().CPIDS, err)
+		if cl.FCM != nil {
+			if _, err := cl.FCM.SubscribeToTopic(ctx, []string{string(obj.Token)}, cu.ID.toString()); err != nil {
+				Warnf(ctx, "attempted to update sub: %q: %v", obj.Token, err)
 			}
-			Warnf(ctx, "batch send response: %v", responses)
-		}()
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Message": msg})
+	}
+}
+
+// txStartInvitation starts the game of the invitation, saves the game, and removes the invitation.
+// As it writes, it must follow all reads of the transaction.
+// Returns a message announcing the start of the game.
+func (cl *GameClient[GT, G]) txStartInvitation(ctx context.Context, tx Tx, inv invitation, cu *User) (string, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	g, cpid, err := cl.startGame(ctx, inv.Header)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cl.txSaveStarted(ctx, tx, g, cu.ID,
+		startedNotification(g), turnNotification(g, g.header().CPIDS), filledNotification(g)); err != nil {
+		return "", err
+	}
 
-		ctx.JSON(http.StatusOK, gin.H{"Message": inv.startGameMessage(cpid)})
+	if err := cl.txDeleteInvitation(tx, inv.id()); err != nil {
+		return "", err
 	}
+	return inv.startGameMessage(cpid), nil
+}
+
+// startGame returns a new game started per the header.
+// The id of the game is the id of the header.
+func (cl *GameClient[GT, G]) startGame(ctx context.Context, h Header) (G, PID, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	g := G(new(GT))
+	cpid, err := g.Start(ctx, h)
+	if err != nil {
+		return nil, NoPID, err
+	}
+	return g, cpid, nil
+}
+
+// txSaveStarted saves a newly started game and adds the notifications, announcing the start, to the outbox
+func (cl *GameClient[GT, G]) txSaveStarted(ctx context.Context, tx Tx, g G, uid UID, ns ...notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	g.header().UpdatedAt = timestamppb.Now()
+	if err := cl.txSave(ctx, tx, g, uid); err != nil {
+		return err
+	}
+	return cl.txNotify(tx, ns...)
 }
 
 // Returns (true, nil) if game should be started
-func (inv *invitation) acceptWith(ctx context.Context, u *User, pwd, hash []byte) (bool, error) {
+func (inv *invitation) acceptWith(ctx context.Context, u *User, pwdErr error) (bool, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	err := inv.validateAcceptWith(ctx, u, pwd, hash)
+	err := inv.validateAcceptWith(ctx, u, pwdErr)
 	if err != nil {
 		return false, err
 	}
 
+	inv.removeInvitee(u.ID)
 	inv.addUser(u)
 	if len(inv.UserIDS) == int(inv.NumPlayers) {
 		return true, nil
@@ -359,23 +406,30 @@ func (inv *invitation) acceptWith(ctx context.Context, u *User, pwd, hash []byte
 	return false, nil
 }
 
-func (inv *invitation) validateAcceptWith(ctx context.Context, u *User, pwd, hash []byte) error {
+// validateAcceptWith validates the user may accept the invitation.
+// pwdErr provides the result of comparing the password provided by the user with the password of the invitation, if any.
+func (inv *invitation) validateAcceptWith(ctx context.Context, u *User, pwdErr error) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	switch {
+	case inv.Status != Recruiting:
+		return fmt.Errorf("game is no longer recruiting: %w", ErrValidation)
+	case inv.expired(time.Now()):
+		return fmt.Errorf("invitation %s has expired: %w", inv.Title, ErrValidation)
 	case len(inv.UserIDS) >= int(inv.NumPlayers):
 		return fmt.Errorf("game already has the maximum number of players: %w", ErrValidation)
 	case inv.hasUser(u):
 		return fmt.Errorf("%s has already accepted this invitation: %w", u.Name, ErrValidation)
-	case len(hash) != 0:
-		err := bcrypt.CompareHashAndPassword(hash, pwd)
-		if err != nil {
-			Warnf(ctx, "%v", err.Error())
-			return fmt.Errorf("%s provided incorrect password for Game %s: %w",
-				u.Name, inv.Title, ErrValidation)
-		}
+	case inv.invited(u.ID):
+		// Invitees have a reserved seat and need no password
 		return nil
+	case inv.openSeats() <= 0:
+		return fmt.Errorf("remaining seats of game are reserved for invited users: %w", ErrValidation)
+	case inv.Private && pwdErr != nil:
+		Warnf(ctx, "%v", pwdErr.Error())
+		return fmt.Errorf("%s provided incorrect password for Game %s: %w",
+			u.Name, inv.Title, ErrValidation)
 	default:
 		return nil
 	}
@@ -413,9 +467,14 @@ func (cl *GameClient[GT, G]) dropHandler() gin.HandlerFunc {
 			return
 		}
 
+		// The creator role passes to the earliest remaining joiner when the creator drops
+		if inv.CreatorID == cu.ID && len(inv.UserIDS) != 0 {
+			inv.addCreator(inv.users()[0])
+		}
+
 		if len(inv.UserIDS) != 0 {
 			inv.UpdatedAt = timestamppb.Now()
-			_, err = cl.invitationDocRef(inv.id()).Set(ctx, inv)
+			err = cl.store.SetInvitation(ctx, inv.id(), inv)
 		} else {
 			err = cl.deleteInvitation(ctx, inv.id())
 		}
@@ -460,39 +519,43 @@ func (h *Header) hasUser(u *User) bool {
 	return pie.Contains(h.UserIDS, u.ID)
 }
 
-func (inv *invitation) removeUser(u2 *User) {
-	i, found := inv.IndexFor(u2.ID)
+func (h *Header) removeUser(u2 *User) {
+	i, found := h.IndexFor(u2.ID)
 	if !found {
 		return
 	}
 
+	h.padEmailReminders()
 	start := int(i)
 	end := start + 1
 
-	inv.UserIDS = slices.Delete(inv.UserIDS, start, end)
-	inv.UserNames = slices.Delete(inv.UserNames, start, end)
-	inv.UserEmails = slices.Delete(inv.UserEmails, start, end)
-	inv.UserEmailHashes = slices.Delete(inv.UserEmailHashes, start, end)
-	inv.UserEmailNotifications = slices.Delete(inv.UserEmailNotifications, start, end)
-	inv.UserGravTypes = slices.Delete(inv.UserGravTypes, start, end)
+	h.UserIDS = slices.Delete(h.UserIDS, start, end)
+	h.UserNames = slices.Delete(h.UserNames, start, end)
+	h.UserEmails = slices.Delete(h.UserEmails, start, end)
+	h.UserEmailHashes = slices.Delete(h.UserEmailHashes, start, end)
+	h.UserEmailNotifications = slices.Delete(h.UserEmailNotifications, start, end)
+	h.UserEmailReminders = slices.Delete(h.UserEmailReminders, start, end)
+	h.UserGravTypes = slices.Delete(h.UserGravTypes, start, end)
 }
 
-func (inv *invitation) addUser(u *User) {
-	inv.UserIDS = append(inv.UserIDS, u.ID)
-	inv.UserNames = append(inv.UserNames, u.Name)
-	inv.UserEmails = append(inv.UserEmails, u.Email)
-	inv.UserEmailHashes = append(inv.UserEmailHashes, u.EmailHash)
-	inv.UserEmailNotifications = append(inv.UserEmailNotifications, u.EmailNotifications)
-	inv.UserGravTypes = append(inv.UserGravTypes, u.GravType)
+func (h *Header) addUser(u *User) {
+	h.padEmailReminders()
+	h.UserIDS = append(h.UserIDS, u.ID)
+	h.UserNames = append(h.UserNames, u.Name)
+	h.UserEmails = append(h.UserEmails, u.Email)
+	h.UserEmailHashes = append(h.UserEmailHashes, u.EmailHash)
+	h.UserEmailNotifications = append(h.UserEmailNotifications, u.EmailNotifications)
+	h.UserEmailReminders = append(h.UserEmailReminders, u.EmailReminders)
+	h.UserGravTypes = append(h.UserGravTypes, u.GravType)
 }
 
-func (inv *invitation) addCreator(u *User) {
-	inv.CreatorID = u.ID
-	inv.CreatorName = u.Name
-	inv.CreatorEmail = u.Email
-	inv.CreatorEmailHash = u.EmailHash
-	inv.CreatorEmailNotifications = u.EmailNotifications
-	inv.CreatorGravType = u.GravType
+func (h *Header) addCreator(u *User) {
+	h.CreatorID = u.ID
+	h.CreatorName = u.Name
+	h.CreatorEmail = u.Email
+	h.CreatorEmailHash = u.EmailHash
+	h.CreatorEmailNotifications = u.EmailNotifications
+	h.CreatorGravType = u.GravType
 }
 
 type detail struct {
@@ -520,41 +583,53 @@ func (cl *GameClient[GT, G]) detailsHandler() gin.HandlerFunc {
 			return
 		}
 
-		uids := make([]UID, len(inv.UserIDS))
-		copy(uids, inv.UserIDS)
-
-		us := make([]*User, len(inv.users()))
-		copy(us, inv.users())
-
-		if hasUID := pie.Any(inv.UserIDS, func(id UID) bool { return id == cu.ID }); !hasUID {
-			uids = append(uids, cu.ID)
-			us = append(us, cu)
-		}
-
-		elos, err := cl.getElos(ctx, us...)
+		details, err := cl.getDetails(ctx, &inv, cu)
 		if err != nil {
 			JErr(ctx, err)
 			return
 		}
 
-		ustats, err := cl.getUStats(ctx, uids...)
-		if err != nil {
-			JErr(ctx, err)
-			return
-		}
+		ctx.JSON(http.StatusOK, gin.H{"Details": details})
+	}
+}
 
-		details := make([]detail, len(elos))
-		for i := range elos {
-			played, won, wp := ustats[i].Played, ustats[i].Won, ustats[i].WinPercentage
-			details[i] = detail{
-				ID:     uids[i],
-				ELO:    elos[i].Rating,
-				Played: played,
-				Won:    won,
-				WP:     wp,
-			}
-		}
+// getDetails returns the rating and stats of the users of the invitation, followed by those of the current user,
+// if the current user has not joined the invitation.
+func (cl *GameClient[GT, G]) getDetails(ctx *gin.Context, inv *invitation, cu *User) ([]detail, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
 
-		ctx.JSON(http.StatusOK, gin.H{"Details": details})
+	uids := make([]UID, len(inv.UserIDS))
+	copy(uids, inv.UserIDS)
+
+	us := make([]*User, len(inv.users()))
+	copy(us, inv.users())
+
+	if hasUID := pie.Any(inv.UserIDS, func(id UID) bool { return id == cu.ID }); !hasUID {
+		uids = append(uids, cu.ID)
+		us = append(us, cu)
+	}
+
+	ratings, err := cl.getRatings(ctx, inv.Type, inv.NumPlayers, us...)
+	if err != nil {
+		return nil, err
+	}
+
+	ustats, err := cl.getUStats(ctx, uids...)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]detail, len(ratings))
+	for i := range ratings {
+		played, won, wp := ustats[i].Played, ustats[i].Won, ustats[i].WinPercentage
+		details[i] = detail{
+			ID:     uids[i],
+			ELO:    ratings[i].Rating,
+			Played: played,
+			Won:    won,
+			WP:     wp,
+		}
 	}
+	return details, nil
 }
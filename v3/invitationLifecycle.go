@@ -0,0 +1,533 @@
+package sn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultInvitationTTL provides the time after which invitations expire, if the creator does not specify otherwise
+	defaultInvitationTTL = 14 * 24 * time.Hour
+
+	// maxInvitationTTL provides the maximum time after which invitations expire
+	maxInvitationTTL = 60 * 24 * time.Hour
+)
+
+// PlayerRanger interface provides an optional hook for games supporting a range of player counts.
+// Invitations of games implementing PlayerRanger may be started with fewer than NumPlayers players,
+// provided at least min players have joined.
+type PlayerRanger interface {
+	PlayerRange() (min, max int)
+}
+
+// InvitationClosedReason provides the reason an invitation was closed before its game started
+type InvitationClosedReason string
+
+const (
+	// ExpiredReason indicates the invitation expired before filling
+	ExpiredReason InvitationClosedReason = "expired"
+
+	// CancelledReason indicates the creator cancelled the invitation
+	CancelledReason InvitationClosedReason = "cancelled"
+
+	// KickedReason indicates the creator removed the user from the invitation
+	KickedReason InvitationClosedReason = "kicked"
+
+	// DeclinedReason indicates an invitee declined an invitation cancelled upon decline
+	DeclinedReason InvitationClosedReason = "declined"
+)
+
+// setExpiry sets the invitation to expire the number of days after now.
+// Zero days provides the default expiry.
+func (h *Header) setExpiry(days int, now time.Time) error {
+	ttl := time.Duration(days) * 24 * time.Hour
+	switch {
+	case ttl < 0 || ttl > maxInvitationTTL:
+		return fmt.Errorf("expiry must be between 0 and %d days: %w", int(maxInvitationTTL.Hours()/24), ErrValidation)
+	case ttl == 0:
+		ttl = defaultInvitationTTL
+	}
+	h.ExpiresAt = timestamppb.New(now.Add(ttl))
+	return nil
+}
+
+// expired returns true if the invitation expired at or before t.
+// Invitations created before expiry was introduced do not expire.
+func (h *Header) expired(t time.Time) bool {
+	return h.ExpiresAt != nil && !t.Before(h.ExpiresAt.AsTime())
+}
+
+// closedNotification returns a notification informing the users that the invitation closed for the reason
+func (inv *invitation) closedNotification(reason InvitationClosedReason, us ...*User) notification {
+	rs := make([]recipient, len(us))
+	for i, u := range us {
+		rs[i] = recipientFor(u)
+	}
+	return eventNotification(InvitationClosedEvent, inv.Type, inv.id(), rs, H{
+		"Game":    inv.Title,
+		"Reason":  string(reason),
+		"Creator": inv.CreatorName,
+	})
+}
+
+// otherUsers returns the users of the invitation other than the user having the uid
+func (inv *invitation) otherUsers(uid UID) []*User {
+	return slices.DeleteFunc(inv.users(), func(u *User) bool { return u.ID == uid })
+}
+
+// updateInvitation updates the invitation having the id via update within a transaction.
+// Only the creator may update recruiting invitations that have not expired.
+func (cl *GameClient[GT, G]) updateInvitation(ctx context.Context, id string, cu *User, update func(*invitation, Tx) error) (invitation, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	var inv invitation
+	err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		inv = invitation{}
+		if err := tx.GetInvitation(id, &inv); err != nil {
+			return err
+		}
+		inv.setID(id)
+
+		if err := inv.validateUpdate(cu, time.Now()); err != nil {
+			return err
+		}
+
+		if err := update(&inv, tx); err != nil {
+			return err
+		}
+
+		inv.UpdatedAt = timestamppb.Now()
+		return tx.SetInvitation(id, inv)
+	})
+	return inv, err
+}
+
+func (inv *invitation) validateUpdate(cu *User, now time.Time) error {
+	switch {
+	case inv.CreatorID != cu.ID:
+		return fmt.Errorf("only the creator may update invitation %s: %w", inv.Title, ErrForbidden)
+	case inv.Status != Recruiting:
+		return fmt.Errorf("invitation %s is no longer recruiting: %w", inv.Title, ErrValidation)
+	case inv.expired(now):
+		return fmt.Errorf("invitation %s has expired: %w", inv.Title, ErrValidation)
+	}
+	return nil
+}
+
+// validateNumPlayers returns an error if the number of players is less than the number of joined and invited users,
+// or outside the player range of games implementing PlayerRanger.
+// Other games may have at most maxMatchPlayers players, and must leave a seat to be filled,
+// as they cannot be started before the invitation fills.
+func (cl *GameClient[GT, G]) validateNumPlayers(inv *invitation, n int) error {
+	if seated := len(inv.UserIDS) + len(inv.InviteeIDS); n < max(seated, 1) {
+		return fmt.Errorf("number of players may not be less than the %d users that have joined or been invited: %w",
+			seated, ErrValidation)
+	}
+
+	pr, ok := any(G(new(GT))).(PlayerRanger)
+	if !ok {
+		switch {
+		case n > maxMatchPlayers:
+			return fmt.Errorf("number of players may not exceed %d: %w", maxMatchPlayers, ErrValidation)
+		case n == len(inv.UserIDS):
+			return fmt.Errorf("number of players must exceed the %d users that have joined: %w",
+				len(inv.UserIDS), ErrValidation)
+		}
+		return nil
+	}
+
+	if lo, hi := pr.PlayerRange(); n < lo || n > hi {
+		return fmt.Errorf("number of players must be between %d and %d: %w", lo, hi, ErrValidation)
+	}
+	return nil
+}
+
+// editInvitationHandler updates the settings of an invitation.
+// Only the creator may edit the invitation, and only while it is recruiting.
+// Settings not provided remain unchanged.
+func (cl *GameClient[GT, G]) editInvitationHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		obj := struct {
+			Title           *string
+			NumPlayers      *int
+			OptString       *string
+			Password        *string
+			TurnLimitHours  *int
+			TimeoutAction   TimeoutAction
+			AllowSpectators *bool
+			SpectatorDelay  int
+			ExpiresInDays   *int
+		}{}
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		// Hash outside the transaction, as hashing is slow and transactions may be retried
+		var hash []byte
+		if obj.Password != nil && *obj.Password != "" {
+			if hash, err = bcrypt.GenerateFromPassword([]byte(*obj.Password), bcrypt.DefaultCost); err != nil {
+				JErr(ctx, err)
+				return
+			}
+		}
+
+		inv, err := cl.updateInvitation(ctx, getID(ctx), cu, func(inv *invitation, tx Tx) error {
+			if obj.Title != nil && *obj.Title != "" {
+				inv.Title = *obj.Title
+			}
+
+			if obj.NumPlayers != nil {
+				if err := cl.validateNumPlayers(inv, *obj.NumPlayers); err != nil {
+					return err
+				}
+				inv.NumPlayers = *obj.NumPlayers
+			}
+
+			if obj.OptString != nil {
+				inv.OptString = *obj.OptString
+			}
+
+			if obj.TurnLimitHours != nil {
+				if err := inv.setTurnLimit(*obj.TurnLimitHours, obj.TimeoutAction); err != nil {
+					return err
+				}
+			}
+
+			if obj.AllowSpectators != nil {
+				if err := inv.setSpectators(*obj.AllowSpectators, obj.SpectatorDelay); err != nil {
+					return err
+				}
+			}
+
+			if obj.ExpiresInDays != nil {
+				if err := inv.setExpiry(*obj.ExpiresInDays, time.Now()); err != nil {
+					return err
+				}
+			}
+
+			// An empty password removes the password
+			switch {
+			case obj.Password == nil:
+				return nil
+			case len(hash) == 0:
+				inv.Private = false
+				return tx.DeleteHash(inv.id())
+			default:
+				inv.Private = true
+				return tx.SetHash(inv.id(), hash)
+			}
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Invitation": inv,
+			"Message":    fmt.Sprintf("%s updated game invitation: %s", cu.Name, inv.Title),
+		})
+	}
+}
+
+// cancelInvitationHandler removes an invitation and notifies the other users that joined it.
+// Only the creator may cancel the invitation.
+func (cl *GameClient[GT, G]) cancelInvitationHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		id := getID(ctx)
+		var inv invitation
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			inv = invitation{}
+			if err := tx.GetInvitation(id, &inv); err != nil {
+				return err
+			}
+			inv.setID(id)
+
+			// Expired invitations may also be cancelled, thus the zero time
+			if err := inv.validateUpdate(cu, time.Time{}); err != nil {
+				return err
+			}
+
+			if err := cl.txDeleteInvitation(tx, id); err != nil {
+				return err
+			}
+			us := append(inv.otherUsers(cu.ID), inv.invitees()...)
+			return cl.txNotify(tx, inv.closedNotification(CancelledReason, us...))
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+		cl.kickOutbox()
+
+		for _, uid := range inv.UserIDS {
+			if err := cl.removeSubs(ctx, id, uid); err != nil {
+				Warnf(ctx, "error removing subs for %v: %v", uid, err)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Message": fmt.Sprintf("%s cancelled game invitation: %s", cu.Name, inv.Title)})
+	}
+}
+
+// kickHandler removes a user from an invitation, or revokes the invite of a user invited directly.
+// Only the creator may kick users, and the creator may not kick themself.
+func (cl *GameClient[GT, G]) kickHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj struct{ UID UID }
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var kicked *User
+		inv, err := cl.updateInvitation(ctx, getID(ctx), cu, func(inv *invitation, tx Tx) error {
+			i, found := inv.IndexFor(obj.UID)
+			switch {
+			case obj.UID == cu.ID:
+				return fmt.Errorf("the creator may not kick themself, but may cancel the invitation: %w", ErrValidation)
+			case inv.invited(obj.UID):
+				kicked = inv.invitees()[slices.Index(inv.InviteeIDS, obj.UID)]
+				inv.removeInvitee(obj.UID)
+				return cl.txNotify(tx, inv.closedNotification(KickedReason, kicked))
+			case !found:
+				return fmt.Errorf("user %d has not joined invitation %s: %w", obj.UID, inv.Title, ErrValidation)
+			}
+
+			kicked = inv.users()[i]
+			inv.removeUser(kicked)
+			return cl.txNotify(tx, inv.closedNotification(KickedReason, kicked))
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+		cl.kickOutbox()
+
+		if err := cl.removeSubs(ctx, inv.id(), kicked.ID); err != nil {
+			Warnf(ctx, "error removing subs for %v: %v", kicked.ID, err)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Invitation": inv,
+			"Message":    fmt.Sprintf("%s removed %s from game invitation: %s", cu.Name, kicked.Name, inv.Title),
+		})
+	}
+}
+
+// reorderHandler reorders the users of an invitation, thereby setting the seating of the started game.
+// The provided user ids must be a permutation of the user ids of the invitation.
+func (cl *GameClient[GT, G]) reorderHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj struct{ UserIDS []UID }
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		inv, err := cl.updateInvitation(ctx, getID(ctx), cu, func(inv *invitation, _ Tx) error {
+			return inv.reorder(obj.UserIDS)
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Invitation": inv,
+			"Message":    fmt.Sprintf("%s reordered players of game invitation: %s", cu.Name, inv.Title),
+		})
+	}
+}
+
+func (inv *invitation) reorder(uids []UID) error {
+	sorted := slices.Sorted(slices.Values(uids))
+	if !slices.Equal(sorted, slices.Sorted(slices.Values(inv.UserIDS))) {
+		return fmt.Errorf("order must include each user of the invitation exactly once: %w", ErrValidation)
+	}
+
+	us := inv.users()
+	inv.UserIDS, inv.UserNames, inv.UserEmails, inv.UserEmailHashes = nil, nil, nil, nil
+	inv.UserEmailNotifications, inv.UserEmailReminders, inv.UserGravTypes = nil, nil, nil
+	for _, uid := range uids {
+		inv.addUser(us[slices.IndexFunc(us, func(u *User) bool { return u.ID == uid })])
+	}
+	return nil
+}
+
+// startNowHandler starts the game of an invitation with the users that have joined.
+// Only the creator may start the game, and only for games implementing PlayerRanger
+// having at least the minimum number of players joined.
+func (cl *GameClient[GT, G]) startNowHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		// Read and validate the invitation within the transaction,
+		// lest a user join or leave after the invitation is validated
+		var msg string
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			inv, err := cl.txGetInvitation(tx, getID(ctx))
+			if err != nil {
+				return err
+			}
+
+			if err := cl.validateStartNow(&inv, cu); err != nil {
+				return err
+			}
+
+			msg, err = cl.txStartInvitation(ctx, tx, inv, cu)
+			return err
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		cl.kickOutbox()
+		ctx.JSON(http.StatusOK, gin.H{"Message": msg})
+	}
+}
+
+// validateStartNow validates the start of the invitation with the users that have joined,
+// forfeiting the seats reserved for invitees that have yet to accept.
+func (cl *GameClient[GT, G]) validateStartNow(inv *invitation, cu *User) error {
+	if err := inv.validateUpdate(cu, time.Now()); err != nil {
+		return err
+	}
+
+	if _, ok := any(G(new(GT))).(PlayerRanger); !ok {
+		return fmt.Errorf("game does not support starting with fewer players: %w", ErrValidation)
+	}
+
+	inv.InviteeIDS, inv.InviteeNames, inv.InviteeEmails, inv.InviteeEmailNotifications = nil, nil, nil, nil
+	if err := cl.validateNumPlayers(inv, len(inv.UserIDS)); err != nil {
+		return err
+	}
+
+	inv.NumPlayers = len(inv.UserIDS)
+	return nil
+}
+
+// expireInvitationsHandler removes expired invitations and notifies the users that joined them.
+// Intended to be periodically invoked by App Engine cron.
+func (cl *GameClient[GT, G]) expireInvitationsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if err := cl.requireCron(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		now := time.Now()
+		docs, err := cl.store.ListExpiredInvitations(ctx, now)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var expired int
+		for _, doc := range docs {
+			ok, err := cl.expireInvitation(ctx, doc.ID(), now)
+			if err != nil {
+				Warnf(ctx, "unable to expire invitation %s: %v", doc.ID(), err)
+				continue
+			}
+			if ok {
+				expired++
+			}
+		}
+		cl.kickOutbox()
+
+		ctx.JSON(http.StatusOK, gin.H{"Expired": expired})
+	}
+}
+
+// expireInvitation removes the invitation, if still recruiting and expired at now.
+// Returns true if the invitation was removed.
+func (cl *GameClient[GT, G]) expireInvitation(ctx *gin.Context, id string, now time.Time) (bool, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	var (
+		inv     invitation
+		removed bool
+	)
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		inv, removed = invitation{}, false
+		if err := tx.GetInvitation(id, &inv); err != nil {
+			return err
+		}
+		inv.setID(id)
+
+		if inv.Status != Recruiting || !inv.expired(now) {
+			return nil
+		}
+
+		if err := cl.txDeleteInvitation(tx, id); err != nil {
+			return err
+		}
+		removed = true
+		us := append(inv.users(), inv.invitees()...)
+		return cl.txNotify(tx, inv.closedNotification(ExpiredReason, us...))
+	}); err != nil || !removed {
+		return false, err
+	}
+
+	for _, uid := range inv.UserIDS {
+		if err := cl.removeSubs(ctx, id, uid); err != nil {
+			Warnf(ctx, "error removing subs for %v: %v", uid, err)
+		}
+	}
+	return true, nil
+}
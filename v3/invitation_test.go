@@ -0,0 +1,212 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testInvitationID = "test-invitation"
+
+func testUser(uid UID) *User {
+	return &User{ID: uid, userData: userData{Name: "user" + uid.toString(), Email: "user" + uid.toString() + "@example.com"}}
+}
+
+// saveTestInvitation saves a recruiting invitation for numPlayers players, created by the first of the users
+func saveTestInvitation(t *testing.T, cl *testClient, numPlayers int, uids ...UID) {
+	t.Helper()
+
+	inv := invitation{Header{Type: "test", Title: "test invitation", NumPlayers: numPlayers, Status: Recruiting}}
+	inv.addCreator(testUser(uids[0]))
+	for _, uid := range uids {
+		inv.addUser(testUser(uid))
+	}
+
+	if err := cl.store.SetInvitation(context.Background(), testInvitationID, inv); err != nil {
+		t.Fatalf("SetInvitation: %v", err)
+	}
+}
+
+func getTestInvitation(t *testing.T, cl *testClient) (invitation, error) {
+	t.Helper()
+
+	var inv invitation
+	err := cl.store.GetInvitation(context.Background(), testInvitationID, &inv)
+	return inv, err
+}
+
+func TestAcceptJoinsInvitation(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/accept/:id", cl.acceptHandler())
+	saveTestInvitation(t, cl, 3, 1)
+
+	w := serve(cl, http.MethodPut, "/invitation/accept/"+testInvitationID, 2, "")
+	if code := w.Header().Get(ErrorCodeHeader); code != "" {
+		t.Fatalf("accept failed with %s: %s", code, w.Body)
+	}
+
+	inv, err := getTestInvitation(t, cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.UserIDS) != 2 || inv.UserIDS[1] != 2 || inv.Status != Recruiting {
+		t.Errorf("UserIDS = %v, Status = %v, want [1 2] and recruiting", inv.UserIDS, inv.Status)
+	}
+
+	// the join notification is added to the outbox with the accept
+	docs, err := cl.store.ListOutbox(context.Background(), time.Now(), outboxBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Errorf("len(ListOutbox) = %d, want 1", len(docs))
+	}
+
+	// accepting again is rejected, and leaves the invitation unchanged
+	w = serve(cl, http.MethodPut, "/invitation/accept/"+testInvitationID, 2, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+		t.Errorf("repeat accept code = %q, want %q", code, ValidationCode)
+	}
+}
+
+func TestAcceptStartsFilledInvitation(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/accept/:id", cl.acceptHandler())
+	saveTestInvitation(t, cl, 2, 1)
+
+	w := serve(cl, http.MethodPut, "/invitation/accept/"+testInvitationID, 2, "")
+	if code := w.Header().Get(ErrorCodeHeader); code != "" {
+		t.Fatalf("accept failed with %s: %s", code, w.Body)
+	}
+
+	if _, err := getTestInvitation(t, cl); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInvitation = %v, want ErrNotFound", err)
+	}
+
+	var i index
+	if err := cl.store.GetIndex(context.Background(), testInvitationID, &i); err != nil {
+		t.Fatalf("GetIndex: %v", err)
+	}
+	if i.Status != Running || len(i.UserIDS) != 2 {
+		t.Errorf("Status = %v, UserIDS = %v, want running with two users", i.Status, i.UserIDS)
+	}
+}
+
+func TestAcceptRemovedInvitation(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/accept/:id", cl.acceptHandler())
+
+	w := serve(cl, http.MethodPut, "/invitation/accept/"+testInvitationID, 2, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != NotFoundCode {
+		t.Errorf("code = %q, want %q", code, NotFoundCode)
+	}
+
+	// the accept neither restores the removed invitation, nor notifies users of the join
+	if docs, err := cl.store.ListOutbox(context.Background(), time.Now(), outboxBatchSize); err != nil || len(docs) != 0 {
+		t.Errorf("ListOutbox = %d docs, %v, want none", len(docs), err)
+	}
+
+	if _, err := getTestInvitation(t, cl); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInvitation = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEditNumPlayersLeavesSeatToFill(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/edit/:id", cl.editInvitationHandler())
+	saveTestInvitation(t, cl, 4, 1, 2)
+
+	for _, n := range []int{2, maxMatchPlayers + 1} {
+		w := serve(cl, http.MethodPut, "/invitation/edit/"+testInvitationID, 1, fmt.Sprintf(`{"NumPlayers":%d}`, n), "Content-Type", "application/json")
+		if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+			t.Errorf("NumPlayers %d: code = %q, want %q", n, code, ValidationCode)
+		}
+	}
+
+	w := serve(cl, http.MethodPut, "/invitation/edit/"+testInvitationID, 1, `{"NumPlayers":3}`, "Content-Type", "application/json")
+	if code := w.Header().Get(ErrorCodeHeader); code != "" {
+		t.Fatalf("edit failed with %s: %s", code, w.Body)
+	}
+
+	inv, err := getTestInvitation(t, cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.NumPlayers != 3 || inv.Status != Recruiting {
+		t.Errorf("NumPlayers = %d, Status = %v, want 3 and recruiting", inv.NumPlayers, inv.Status)
+	}
+}
+
+func TestStartNowRequiresPlayerRanger(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/start/:id", cl.startNowHandler())
+	saveTestInvitation(t, cl, 3, 1, 2)
+
+	w := serve(cl, http.MethodPut, "/invitation/start/"+testInvitationID, 1, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+		t.Errorf("code = %q, want %q", code, ValidationCode)
+	}
+
+	if _, err := getTestInvitation(t, cl); err != nil {
+		t.Errorf("GetInvitation = %v, want the invitation retained", err)
+	}
+
+	// a removed invitation is not started
+	if err := cl.store.RunTransaction(context.Background(), func(_ context.Context, tx Tx) error {
+		return tx.DeleteInvitation(testInvitationID)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	w = serve(cl, http.MethodPut, "/invitation/start/"+testInvitationID, 1, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != NotFoundCode {
+		t.Errorf("removed invitation code = %q, want %q", code, NotFoundCode)
+	}
+}
+
+// saveTestUsers saves the users, as if by the user service
+func saveTestUsers(t *testing.T, cl *testClient, uids ...UID) {
+	t.Helper()
+
+	for _, uid := range uids {
+		if err := cl.store.(*memStore).set(userPath(uid), testUser(uid).userData); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateInvitationLooksUpInvitees(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/invitation/new", cl.createInvitationHandler())
+	saveTestUsers(t, cl, 2)
+
+	// unknown users may not be invited
+	w := serve(cl, http.MethodPut, "/invitation/new", 1, `{"Type":"test","NumPlayers":3,"Invitees":[{"ID":3}]}`,
+		"Content-Type", "application/json")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+		t.Errorf("unknown invitee code = %q, want %q", code, ValidationCode)
+	}
+
+	// the name and email of an invitee are those of the saved user, rather than the request
+	w = serve(cl, http.MethodPut, "/invitation/new", 1, `{"Type":"test","NumPlayers":3,"Invitees":[{"ID":2,"Name":"spoofed"}]}`,
+		"Content-Type", "application/json")
+	if code := w.Header().Get(ErrorCodeHeader); code != "" {
+		t.Fatalf("create failed with %s: %s", code, w.Body)
+	}
+
+	docs, err := cl.store.ListOutbox(context.Background(), time.Now(), outboxBatchSize)
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("ListOutbox = %d docs, %v, want 1 doc", len(docs), err)
+	}
+
+	var n notification
+	if err := docs[0].DataTo(&n); err != nil {
+		t.Fatal(err)
+	}
+	want := testUser(2)
+	if len(n.Recipients) != 1 || n.Recipients[0].Name != want.Name || n.Recipients[0].Email != want.Email {
+		t.Errorf("Recipients = %+v, want %s <%s>", n.Recipients, want.Name, want.Email)
+	}
+}
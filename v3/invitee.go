@@ -0,0 +1,204 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// invitee identifies a user invited directly to an invitation
+type invitee struct {
+	ID UID
+}
+
+// getInvitees returns the users identified by the invitees.
+// The name and email of each user is provided by the saved user, rather than the request.
+func (cl *Client) getInvitees(ctx context.Context, invitees []invitee) ([]*User, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	us := make([]*User, len(invitees))
+	for i, invitee := range invitees {
+		if invitee.ID == noUID {
+			return nil, fmt.Errorf("invited users must be identified: %w", ErrValidation)
+		}
+
+		u := &User{ID: invitee.ID}
+		err := cl.store.GetUser(ctx, invitee.ID, &u.userData)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return nil, fmt.Errorf("invited user %d does not exist: %w", invitee.ID, ErrValidation)
+		case err != nil:
+			return nil, err
+		}
+		us[i] = u
+	}
+	return us, nil
+}
+
+// invite reserves seats of the invitation for the invited users
+func (inv *invitation) invite(us []*User, cancelOnDecline bool) error {
+	for _, u := range us {
+		if inv.isPlayer(u.ID) || inv.invited(u.ID) {
+			return fmt.Errorf("user %d may be invited only once: %w", u.ID, ErrValidation)
+		}
+		inv.InviteeIDS = append(inv.InviteeIDS, u.ID)
+		inv.InviteeNames = append(inv.InviteeNames, u.Name)
+		inv.InviteeEmails = append(inv.InviteeEmails, u.Email)
+		inv.InviteeEmailNotifications = append(inv.InviteeEmailNotifications, u.EmailNotifications)
+	}
+
+	if inv.openSeats() < 0 {
+		return fmt.Errorf("%d users were invited, but game has only %d open seats: %w",
+			len(us), inv.NumPlayers-len(inv.UserIDS), ErrValidation)
+	}
+	inv.CancelOnDecline = cancelOnDecline
+	return nil
+}
+
+// invited returns true if the user was invited directly to the invitation and has yet to accept or decline
+func (h *Header) invited(uid UID) bool {
+	return slices.Contains(h.InviteeIDS, uid)
+}
+
+// openSeats returns the number of seats neither taken by joined users nor reserved for invitees
+func (h *Header) openSeats() int {
+	return h.NumPlayers - len(h.UserIDS) - len(h.InviteeIDS)
+}
+
+func (h *Header) removeInvitee(uid UID) {
+	i := slices.Index(h.InviteeIDS, uid)
+	if i < 0 {
+		return
+	}
+	h.InviteeIDS = slices.Delete(h.InviteeIDS, i, i+1)
+	h.InviteeNames = slices.Delete(h.InviteeNames, i, i+1)
+	// Invitations created prior to the introduction of InviteeEmails lack the emails of invitees
+	if i < len(h.InviteeEmails) {
+		h.InviteeEmails = slices.Delete(h.InviteeEmails, i, i+1)
+		h.InviteeEmailNotifications = slices.Delete(h.InviteeEmailNotifications, i, i+1)
+	}
+}
+
+// invitees returns the users invited directly to the invitation that have yet to accept or decline
+func (h *Header) invitees() []*User {
+	us := make([]*User, len(h.InviteeIDS))
+	for i, uid := range h.InviteeIDS {
+		us[i] = &User{ID: uid, userData: userData{Name: h.InviteeNames[i]}}
+		if i < len(h.InviteeEmails) {
+			us[i].Email = h.InviteeEmails[i]
+			us[i].EmailNotifications = h.InviteeEmailNotifications[i]
+		}
+	}
+	return us
+}
+
+// invitedNotification returns a notification informing the invitees of their invite
+func (inv *invitation) invitedNotification() notification {
+	rs := make([]recipient, len(inv.InviteeIDS))
+	for i, u := range inv.invitees() {
+		rs[i] = recipientFor(u)
+	}
+	return eventNotification(InvitedEvent, inv.Type, inv.id(), rs, H{
+		"Game":    inv.Title,
+		"Creator": inv.CreatorName,
+	})
+}
+
+// inboxHandler returns the recruiting invitations to which the current user was invited directly
+func (cl *GameClient[GT, G]) inboxHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		docs, err := cl.store.ListInvites(ctx, cu.ID)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		now := time.Now()
+		invs := make([]invitation, 0, len(docs))
+		for _, doc := range docs {
+			var inv invitation
+			if err := doc.DataTo(&inv); err != nil {
+				JErr(ctx, err)
+				return
+			}
+			inv.setID(doc.ID())
+
+			if inv.Status == Recruiting && !inv.expired(now) {
+				invs = append(invs, inv.public())
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Invitations": invs})
+	}
+}
+
+// declineHandler declines the invite of the current user to an invitation.
+// The seat of the user reverts to open, unless the creator chose to cancel the invitation upon decline.
+func (cl *GameClient[GT, G]) declineHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		id := getID(ctx)
+		var inv invitation
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			inv = invitation{}
+			if err := tx.GetInvitation(id, &inv); err != nil {
+				return err
+			}
+			inv.setID(id)
+
+			switch {
+			case inv.Status != Recruiting:
+				return fmt.Errorf("game is no longer recruiting: %w", ErrValidation)
+			case !inv.invited(cu.ID):
+				return fmt.Errorf("%s was not invited to %s: %w", cu.Name, inv.Title, ErrValidation)
+			}
+
+			inv.removeInvitee(cu.ID)
+			if !inv.CancelOnDecline {
+				inv.UpdatedAt = timestamppb.Now()
+				if err := tx.SetInvitation(id, inv); err != nil {
+					return err
+				}
+				return cl.txNotify(tx, eventNotification(InvitationDeclinedEvent, inv.Type, id,
+					[]recipient{inv.creatorRecipient()}, H{"Game": inv.Title, "Invitee": cu.Name}))
+			}
+
+			if err := cl.txDeleteInvitation(tx, id); err != nil {
+				return err
+			}
+			n := inv.closedNotification(DeclinedReason, append(inv.users(), inv.invitees()...)...)
+			n.Data["Invitee"] = cu.Name
+			return cl.txNotify(tx, n)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+		cl.kickOutbox()
+
+		ctx.JSON(http.StatusOK, gin.H{"Message": fmt.Sprintf("%s declined game invitation: %s", cu.Name, inv.Title)})
+	}
+}
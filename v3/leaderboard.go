@@ -0,0 +1,272 @@
+package sn
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLeaderboardLimit = 25
+	maxLeaderboardLimit     = 100
+)
+
+type ratingSort struct {
+	field string
+	desc  bool
+}
+
+// leaderboardSorts maps sort query values to the ordering of ratings.
+// Lower average finishes are better, and thus sort ascending.
+var leaderboardSorts = map[string]ratingSort{
+	"rating": {field: "Rating", desc: true},
+	"wp":     {field: "WinPercentage", desc: true},
+	"played": {field: "Played", desc: true},
+	"finish": {field: "FinishAvg"},
+}
+
+// leaderboardHandler returns a page of ratings of the rating system of the client.
+// As users are rated per game type and player count, a user appears at most once only if both are provided.
+// Otherwise, ratings are listed across game types or player counts, each identified by its Type and NumPlayers.
+// Query parameters:
+//   - type: limits ratings to a game type
+//   - players: limits ratings to a player count
+//   - sort: one of rating (default), wp, played, or finish
+//   - offset, limit: provide pagination
+//
+// Requires composite indices on the System, Type, NumPlayers and sort fields of the Rating collection,
+// and on the subsets thereof used when type or players are omitted.
+func (cl *GameClient[GT, G]) leaderboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		q, err := getRatingQuery(ctx, cl.rater.System())
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if q.NumPlayers < 0 {
+			JErr(ctx, fmt.Errorf("players may not be negative: %w", ErrValidation))
+			return
+		}
+
+		key := ctx.DefaultQuery("sort", "rating")
+		s, ok := leaderboardSorts[key]
+		if !ok {
+			JErr(ctx, fmt.Errorf("unknown sort %q: %w", key, ErrValidation))
+			return
+		}
+		q.OrderBy, q.Desc = s.field, s.desc
+
+		if q.Offset, err = getIntQuery(ctx, "offset", 0); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if q.Limit, err = getIntQuery(ctx, "limit", defaultLeaderboardLimit); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if q.Offset < 0 || q.Limit < 1 || q.Limit > maxLeaderboardLimit {
+			JErr(ctx, fmt.Errorf("offset must be non-negative and limit between 1 and %d: %w",
+				maxLeaderboardLimit, ErrValidation))
+			return
+		}
+
+		ratings, err := cl.listRatings(ctx, q)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		next := -1
+		if len(ratings) == q.Limit {
+			next = q.Offset + q.Limit
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Ratings": ratings, "Offset": q.Offset, "Next": next})
+	}
+}
+
+func (cl *GameClient[GT, G]) listRatings(ctx *gin.Context, q RatingQuery) ([]Rating, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	docs, err := cl.store.ListRatings(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRatings(docs)
+}
+
+// ratingHistoryHandler returns the rating history of a user, oldest first, as a time series.
+// Query parameters type and players limit the history to a game type and player count.
+// Requires composite indices on the System, Type, NumPlayers and UpdatedAt fields of History collections.
+func (cl *GameClient[GT, G]) ratingHistoryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		uid, err := getUIDParam(ctx, "uid")
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		q, err := getRatingQuery(ctx, cl.rater.System())
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+		q.OrderBy = "UpdatedAt"
+
+		docs, err := cl.store.ListRatingHistory(ctx, uid, q)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ratings, err := decodeRatings(docs)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"History": ratings})
+	}
+}
+
+func decodeRatings(docs []Doc) ([]Rating, error) {
+	ratings := make([]Rating, len(docs))
+	for i, doc := range docs {
+		if err := doc.DataTo(&ratings[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ratings, nil
+}
+
+type headToHeadGame struct {
+	ID      string
+	Title   string
+	Type    Type
+	Places  []int
+	EndedAt time.Time
+}
+
+type headToHead struct {
+	UIDS   []UID
+	Played int
+	Wins   []int
+	Draws  int
+	Games  []headToHeadGame
+}
+
+// headToHeadHandler returns the record of completed games played by two users against one another.
+// Query parameter type limits the record to games of a game type.
+// Requires composite indices on the Status, Type and UserIDS fields of the Index collection.
+func (cl *GameClient[GT, G]) headToHeadHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		uid1, err := getUIDParam(ctx, "uid1")
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		uid2, err := getUIDParam(ctx, "uid2")
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if uid1 == uid2 {
+			JErr(ctx, fmt.Errorf("head-to-head requires two different users: %w", ErrValidation))
+			return
+		}
+
+		docs, err := cl.store.ListIndexes(ctx, IndexQuery{Status: Completed, Type: Type(ctx.Query("type")), UserID: uid1})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		h2h := headToHead{UIDS: []UID{uid1, uid2}, Wins: make([]int, 2)}
+		for _, doc := range docs {
+			var i index
+			if err := doc.DataTo(&i); err != nil {
+				JErr(ctx, err)
+				return
+			}
+
+			if !slices.Contains(i.UserIDS, uid2) {
+				continue
+			}
+
+			place1, place2 := i.Places[uid1.toString()], i.Places[uid2.toString()]
+			switch {
+			case place1 < place2:
+				h2h.Wins[0]++
+			case place2 < place1:
+				h2h.Wins[1]++
+			default:
+				h2h.Draws++
+			}
+			h2h.Played++
+
+			var endedAt time.Time
+			if i.EndedAt != nil {
+				endedAt = i.EndedAt.AsTime()
+			}
+			h2h.Games = append(h2h.Games, headToHeadGame{
+				ID:      doc.ID(),
+				Title:   i.Title,
+				Type:    i.Type,
+				Places:  []int{place1, place2},
+				EndedAt: endedAt,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"HeadToHead": h2h})
+	}
+}
+
+// getRatingQuery returns a RatingQuery for the rating system per the type and players query parameters
+func getRatingQuery(ctx *gin.Context, system string) (RatingQuery, error) {
+	numPlayers, err := getIntQuery(ctx, "players", 0)
+	if err != nil {
+		return RatingQuery{}, err
+	}
+	return RatingQuery{System: system, Type: Type(ctx.Query("type")), NumPlayers: numPlayers}, nil
+}
+
+func getIntQuery(ctx *gin.Context, key string, def int) (int, error) {
+	s, found := ctx.GetQuery(key)
+	if !found || s == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, s, ErrValidation)
+	}
+	return i, nil
+}
+
+func getUIDParam(ctx *gin.Context, key string) (UID, error) {
+	s := ctx.Param(key)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid user id %q: %w", s, ErrValidation)
+	}
+	return UID(id), nil
+}
@@ -0,0 +1,63 @@
+package sn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestLeaderboardFiltersAreOptional(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.GET("/leaderboard", cl.leaderboardHandler())
+
+	system := cl.rater.System()
+	if err := cl.store.RunTransaction(context.Background(), func(_ context.Context, tx Tx) error {
+		for _, r := range []Rating{
+			{UID: 1, System: system, Type: "a", NumPlayers: 2, Rating: 1600},
+			{UID: 1, System: system, Type: "b", NumPlayers: 3, Rating: 1500},
+			{UID: 2, System: system, Type: "a", NumPlayers: 3, Rating: 1700},
+		} {
+			if err := tx.SetRating(ratingID(r.UID, r.System, r.Type, r.NumPlayers), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  []int
+	}{
+		{query: "", want: []int{1700, 1600, 1500}},
+		{query: "?type=a", want: []int{1700, 1600}},
+		{query: "?players=3", want: []int{1700, 1500}},
+		{query: "?type=a&players=2", want: []int{1600}},
+	} {
+		w := serve(cl, http.MethodGet, "/leaderboard"+tc.query, 0, "")
+		if code := w.Header().Get(ErrorCodeHeader); code != "" {
+			t.Fatalf("%q: failed with %s: %s", tc.query, code, w.Body)
+		}
+
+		var resp struct{ Ratings []Rating }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]int, len(resp.Ratings))
+		for i, r := range resp.Ratings {
+			got[i] = r.Rating
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%q: ratings = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	w := serve(cl, http.MethodGet, "/leaderboard?players=-1", 0, "")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+		t.Errorf("negative players code = %q, want %q", code, ValidationCode)
+	}
+}
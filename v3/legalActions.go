@@ -0,0 +1,133 @@
+package sn
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegalAction provides an action a player may presently perform.
+// Name identifies the action, e.g., by the path of its handler, and Data provides the parameters of the action.
+type LegalAction struct {
+	Name string
+	Data H `json:",omitempty"`
+}
+
+// LegalActioner interface provides an optional hook for games enumerating the actions
+// a player may perform in the current state of the game.
+// Clients may use the actions to disable illegal moves, and bots to discover moves.
+type LegalActioner interface {
+	LegalActions(PID) []LegalAction
+}
+
+// legalActionsHandler returns the legal actions of the player of the current user,
+// in the game at the stack of the current user.
+// Users without a player in the game, e.g., spectators, have no legal actions.
+func (cl *GameClient[GT, G]) legalActionsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		g, uid, err := cl.getGame(ctx, cu)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		la, ok := any(g).(LegalActioner)
+		if !ok {
+			JErr(ctx, fmt.Errorf("game does not enumerate legal actions: %w", ErrValidation))
+			return
+		}
+
+		actions := []LegalAction{}
+		if pid := g.header().PIDFor(uid); pid != NoPID && g.header().Status == Running {
+			actions = append(actions, la.LegalActions(pid)...)
+		}
+		ctx.JSON(http.StatusOK, gin.H{"Actions": actions})
+	}
+}
+
+// DryRunResponse provides the response of DryRunHandler.
+// Error provides the validation error of an invalid action.
+type DryRunResponse struct {
+	Valid bool
+	Error *ErrorBody `json:",omitempty"`
+}
+
+// DryRunHandler provides a general purpose handler for validating game actions without performing them.
+// The action is run against a copy of the game, and neither the game nor the stack of the user are saved.
+// As the random outcomes of an action follow from the seed of the game, the copy is provided a throwaway seed,
+// and neither the resulting game nor the message of the action are returned, thereby not revealing outcomes.
+// Validation errors of the action are reported in the response, while other errors are returned via JErr.
+func (cl *GameClient[GT, G]) DryRunHandler(action ActionFunc[GT, G]) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		g, _, err := cl.getGame(ctx, cu)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		// Copied, as games may be shared, e.g., by the cache of the client
+		dry := DeepCopy(g)
+		dry.setID(g.id())
+		dry.header().Seed = NewSeed()
+
+		if _, err := action(dry, ctx, cu); err != nil {
+			body, _ := newErrorBody(ctx, err)
+			if body.Code != ValidationCode {
+				JErr(ctx, err)
+				return
+			}
+			ctx.JSON(http.StatusOK, DryRunResponse{Error: &body})
+			return
+		}
+		ctx.JSON(http.StatusOK, DryRunResponse{Valid: true})
+	}
+}
+
+// DryRunner interface provides an optional hook for games validating their actions via the dry run route,
+// thereby sparing games from registering a DryRunHandler per action.
+// DryRunAction returns the action identified by name, e.g., the Name of a LegalAction, if any.
+type DryRunner[GT any, G Gamer[GT]] interface {
+	DryRunAction(name string) (ActionFunc[GT, G], bool)
+}
+
+// dryRunHandler validates the action identified by the action parameter, per DryRunHandler,
+// for games implementing DryRunner.
+func (cl *GameClient[GT, G]) dryRunHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		dr, ok := any(G(new(GT))).(DryRunner[GT, G])
+		if !ok {
+			JErr(ctx, fmt.Errorf("game does not support dry runs: %w", ErrValidation))
+			return
+		}
+
+		name := ctx.Param("action")
+		action, ok := dr.DryRunAction(name)
+		if !ok {
+			JErr(ctx, fmt.Errorf("unknown action %q: %w", name, ErrValidation))
+			return
+		}
+		cl.DryRunHandler(action)(ctx)
+	}
+}
@@ -0,0 +1,67 @@
+package sn
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunAction implements DryRunner, providing a count action that fails for counts above one
+func (g *testGame) DryRunAction(name string) (ActionFunc[testGame, *testGame], bool) {
+	if name != "count" {
+		return nil, false
+	}
+	return func(g *testGame, ctx *gin.Context, _ *User) (Result, error) {
+		var obj struct{ By int }
+		if err := ctx.ShouldBindJSON(&obj); err != nil {
+			return Result{}, err
+		}
+
+		if obj.By > 1 {
+			return Result{}, fmt.Errorf("may count by at most one: %w", ErrValidation)
+		}
+		g.State.Count += obj.By
+		return Result{}, nil
+	}, true
+}
+
+func TestDryRunRoute(t *testing.T) {
+	cl := newTestClient(t)
+	cl.addRoutes(cl.prefix)
+	startTestGame(t, cl, 1, 2)
+
+	path := cl.prefix + "/game/dryrun/count/" + testGID
+	for _, tc := range []struct {
+		body  string
+		valid bool
+	}{
+		{body: `{"By":1}`, valid: true},
+		{body: `{"By":2}`},
+	} {
+		w := serve(cl, http.MethodPut, path, 1, tc.body, "Content-Type", "application/json")
+		if code := w.Header().Get(ErrorCodeHeader); code != "" {
+			t.Fatalf("%s: failed with %s: %s", tc.body, code, w.Body)
+		}
+
+		var resp DryRunResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Valid != tc.valid {
+			t.Errorf("%s: Valid = %v, want %v", tc.body, resp.Valid, tc.valid)
+		}
+	}
+
+	// dry runs perform no action
+	if g := getTestRev(t, cl, 0); g.State.Count != 0 {
+		t.Errorf("Count = %d, want 0", g.State.Count)
+	}
+
+	w := serve(cl, http.MethodPut, cl.prefix+"/game/dryrun/unknown/"+testGID, 1, `{}`, "Content-Type", "application/json")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ValidationCode {
+		t.Errorf("unknown action code = %q, want %q", code, ValidationCode)
+	}
+}
@@ -0,0 +1,336 @@
+package sn
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go"
+)
+
+const (
+	defaultFromEmail = "webmaster@slothninja.com"
+	defaultFromName  = "Webmaster"
+)
+
+// Email provides a transport-neutral email message.
+// If FromEmail is empty, the message is sent from the SlothNinja Games webmaster.
+// At least one of TextPart and HTMLPart should be provided.
+type Email struct {
+	FromEmail string
+	FromName  string
+	ToEmail   string
+	ToName    string
+	Subject   string
+	TextPart  string
+	HTMLPart  string
+}
+
+func (e Email) from() (string, string) {
+	if e.FromEmail == "" {
+		return defaultFromEmail, defaultFromName
+	}
+	return e.FromEmail, e.FromName
+}
+
+// Mailer provides a transport for sending email messages
+type Mailer interface {
+	Send(context.Context, ...Email) error
+}
+
+// WithMailer sets the transport used to send email messages.
+// If not set, messages are sent via Mailjet in production, and otherwise only logged.
+func WithMailer(m Mailer) Option {
+	return func(cl *Client) *Client {
+		cl.mailer = m
+		return cl
+	}
+}
+
+func defaultMailer() Mailer {
+	if IsProduction() {
+		pub, priv := getMJKeys()
+		return NewMailjetMailer(pub, priv)
+	}
+	return logMailer{}
+}
+
+// MailjetMailer sends email messages via the Mailjet v3.1 send API
+type MailjetMailer struct {
+	client *mailjet.Client
+}
+
+// NewMailjetMailer returns a Mailer sending messages via Mailjet using the api keys
+func NewMailjetMailer(publicKey, privateKey string) *MailjetMailer {
+	return &MailjetMailer{client: mailjet.NewMailjetClient(publicKey, privateKey)}
+}
+
+// Send implements Mailer interface
+func (m *MailjetMailer) Send(ctx context.Context, es ...Email) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if len(es) == 0 {
+		return nil
+	}
+
+	msgs := make([]mailjet.InfoMessagesV31, len(es))
+	for i, e := range es {
+		fromEmail, fromName := e.from()
+		msgs[i] = mailjet.InfoMessagesV31{
+			From: &mailjet.RecipientV31{
+				Email: fromEmail,
+				Name:  fromName,
+			},
+			To: &mailjet.RecipientsV31{
+				mailjet.RecipientV31{
+					Email: e.ToEmail,
+					Name:  e.ToName,
+				},
+			},
+			Subject:  e.Subject,
+			TextPart: e.TextPart,
+			HTMLPart: e.HTMLPart,
+		}
+	}
+
+	_, err := m.client.SendMailV31(&mailjet.MessagesV31{Info: msgs})
+	return err
+}
+
+// SMTPMailer sends email messages via an SMTP server.
+// If the server supports STARTTLS, the connection is upgraded prior to authenticating.
+// Authentication requires TLS, unless the server is localhost.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// RequireTLS, if true, causes sending to fail if the server does not support STARTTLS
+	RequireTLS bool
+}
+
+// Send implements Mailer interface
+func (m *SMTPMailer) Send(ctx context.Context, es ...Email) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if len(es) == 0 {
+		return nil
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(cmp.Or(m.Port, 587)))
+	conn, err := new(net.Dialer).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return err
+		}
+	} else if m.RequireTLS {
+		return fmt.Errorf("smtp server %s does not support STARTTLS", m.Host)
+	}
+
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range es {
+		if err := m.send(c, e); err != nil {
+			return err
+		}
+	}
+	return c.Quit()
+}
+
+func (m *SMTPMailer) send(c *smtp.Client, e Email) error {
+	msg, err := e.mime(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fromEmail, _ := e.from()
+	if err := c.Mail(fromEmail); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(e.ToEmail); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// FileMailer writes email messages, in MIME format, to the new directory of the maildir Dir.
+// Useful for development, and for tests asserting on the content of sent messages.
+type FileMailer struct {
+	Dir string
+}
+
+// Send implements Mailer interface
+func (m *FileMailer) Send(ctx context.Context, es ...Email) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.Dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range es {
+		now := time.Now()
+		msg, err := e.mime(now)
+		if err != nil {
+			return err
+		}
+
+		// per maildir, messages are written to tmp and then moved to new, so readers never see partial messages
+		name := fmt.Sprintf("%d.%s.sn", now.UnixNano(), randomHex(8))
+		tmp := filepath.Join(m.Dir, "tmp", name)
+		if err := os.WriteFile(tmp, msg, 0o644); err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmp, filepath.Join(m.Dir, "new", name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logMailer only logs email messages
+type logMailer struct{}
+
+// Send implements Mailer interface
+func (logMailer) Send(ctx context.Context, es ...Email) error {
+	for _, e := range es {
+		Debugf(ctx, "sent message: %#v", e)
+	}
+	return nil
+}
+
+// mime returns the message in MIME format.
+// Messages having both text and html parts are sent as multipart/alternative messages.
+func (e Email) mime(date time.Time) ([]byte, error) {
+	if e.ToEmail == "" {
+		return nil, errors.New("email must have a recipient")
+	}
+
+	fromEmail, fromName := e.from()
+	buf := new(bytes.Buffer)
+	header := textproto.MIMEHeader{}
+	header.Set("From", (&mail.Address{Name: fromName, Address: fromEmail}).String())
+	header.Set("To", (&mail.Address{Name: e.ToName, Address: e.ToEmail}).String())
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("Message-ID", fmt.Sprintf("<%d.%s@slothninja.com>", date.UnixNano(), randomHex(8)))
+	header.Set("MIME-Version", "1.0")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain", e.TextPart},
+		{"text/html", e.HTMLPart},
+	}
+	if e.TextPart == "" {
+		parts = parts[1:]
+	} else if e.HTMLPart == "" {
+		parts = parts[:1]
+	}
+
+	if len(parts) == 1 {
+		header.Set("Content-Type", parts[0].contentType+"; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(buf, header)
+		if err := writeQuotedPrintable(buf, parts[0].body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	for _, part := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	header.Set("Content-Type", "multipart/alternative; boundary="+w.Boundary())
+	writeHeader(buf, header)
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, k := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"} {
+		if v := header.Get(k); v != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, s string) error {
+	qp := quotedprintable.NewWriter(buf)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
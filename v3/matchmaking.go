@@ -0,0 +1,440 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxMatchPlayers provides the largest player count for which a user may queue
+const maxMatchPlayers = 8
+
+// errQueueChanged indicates an entry of a match is no longer queued
+var errQueueChanged = errors.New("queue entry no longer queued")
+
+// queueEntry provides a user waiting to be matched with other users for a game of a game type
+type queueEntry struct {
+	UID                UID
+	Name               string
+	Email              string
+	EmailHash          string
+	EmailNotifications bool
+	EmailReminders     bool
+	GravType           string
+	Type               Type
+	MinPlayers         int
+	MaxPlayers         int
+	OptString          string
+	// EloWindow, if non-zero, limits matches to users having ratings within EloWindow of the rating of the user
+	EloWindow int
+	// Ratings provides the rating of the user for each acceptable player count.
+	// Firestore only supports string values for map keys.
+	Ratings   map[string]int
+	Token     SubToken
+	CreatedAt time.Time
+}
+
+// queueEntryID returns the id of the queue entry of the user for the game type.
+// A user may be queued at most once per game type.
+func queueEntryID(uid UID, t Type) string {
+	return fmt.Sprintf("%d-%s", uid, t)
+}
+
+func (e queueEntry) id() string {
+	return queueEntryID(e.UID, e.Type)
+}
+
+func (e queueEntry) user() *User {
+	return &User{
+		ID: e.UID,
+		userData: userData{
+			Name:               e.Name,
+			Email:              e.Email,
+			EmailHash:          e.EmailHash,
+			EmailNotifications: e.EmailNotifications,
+			EmailReminders:     e.EmailReminders,
+			GravType:           e.GravType,
+		},
+	}
+}
+
+func (e queueEntry) accepts(numPlayers int) bool {
+	return e.MinPlayers <= numPlayers && numPlayers <= e.MaxPlayers
+}
+
+func (e queueEntry) ratingFor(numPlayers int) int {
+	return e.Ratings[strconv.Itoa(numPlayers)]
+}
+
+// acceptsRating returns true if the rating of o is within the Elo window of e for games of numPlayers players
+func (e queueEntry) acceptsRating(o queueEntry, numPlayers int) bool {
+	if e.EloWindow == 0 {
+		return true
+	}
+	diff := e.ratingFor(numPlayers) - o.ratingFor(numPlayers)
+	return max(diff, -diff) <= e.EloWindow
+}
+
+// joinQueueHandler queues the current user for a game of a game type and attempts to match queued users.
+func (cl *GameClient[GT, G]) joinQueueHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		e, err := cl.queueEntryFromForm(ctx, cu)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			return tx.SetQueueEntry(e.id(), e)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		gs, err := cl.match(ctx, e.Type)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		for _, g := range gs {
+			if g.header().isPlayer(cu.ID) {
+				ctx.JSON(http.StatusOK, gin.H{
+					"GID":     g.id(),
+					"Message": fmt.Sprintf("Match found: %s has started.", g.header().Title),
+				})
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Message": fmt.Sprintf("%s queued for a game of %s", cu.Name, e.Type)})
+	}
+}
+
+func (cl *GameClient[GT, G]) queueEntryFromForm(ctx *gin.Context, cu *User) (queueEntry, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	obj := struct {
+		Type       Type
+		MinPlayers int
+		MaxPlayers int
+		OptString  string
+		EloWindow  int
+		Token      SubToken
+	}{}
+
+	if err := ctx.ShouldBind(&obj); err != nil {
+		return queueEntry{}, err
+	}
+
+	switch {
+	case obj.Type == NoType:
+		return queueEntry{}, fmt.Errorf("game type must be provided: %w", ErrValidation)
+	case obj.MinPlayers < 2 || obj.MinPlayers > obj.MaxPlayers || obj.MaxPlayers > maxMatchPlayers:
+		return queueEntry{}, fmt.Errorf("player counts must be between 2 and %d: %w", maxMatchPlayers, ErrValidation)
+	case obj.EloWindow < 0:
+		return queueEntry{}, fmt.Errorf("elo window must not be negative: %w", ErrValidation)
+	}
+
+	e := queueEntry{
+		UID:                cu.ID,
+		Name:               cu.Name,
+		Email:              cu.Email,
+		EmailHash:          cu.EmailHash,
+		EmailNotifications: cu.EmailNotifications,
+		EmailReminders:     cu.EmailReminders,
+		GravType:           cu.GravType,
+		Type:               obj.Type,
+		MinPlayers:         obj.MinPlayers,
+		MaxPlayers:         obj.MaxPlayers,
+		OptString:          obj.OptString,
+		EloWindow:          obj.EloWindow,
+		Ratings:            make(map[string]int),
+		Token:              obj.Token,
+		CreatedAt:          time.Now(),
+	}
+
+	for n := e.MinPlayers; n <= e.MaxPlayers; n++ {
+		ratings, err := cl.getRatings(ctx, e.Type, n, cu)
+		if err != nil {
+			return queueEntry{}, err
+		}
+		e.Ratings[strconv.Itoa(n)] = ratings[0].Rating
+	}
+	return e, nil
+}
+
+// leaveQueueHandler removes the current user from the queue for a game type
+func (cl *GameClient[GT, G]) leaveQueueHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		obj := struct{ Type Type }{}
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		id := queueEntryID(cu.ID, obj.Type)
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			var e queueEntry
+			if err := tx.GetQueueEntry(id, &e); errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%s is not queued for a game of %s: %w", cu.Name, obj.Type, ErrValidation)
+			} else if err != nil {
+				return err
+			}
+			return tx.DeleteQueueEntry(id)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Message": fmt.Sprintf("%s left the queue for %s", cu.Name, obj.Type)})
+	}
+}
+
+// queueStatusHandler returns the queue entries of the current user and the number of users queued per game type
+func (cl *GameClient[GT, G]) queueStatusHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		entries, err := cl.listQueue(ctx, NoType)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		waiting := make(map[Type]int)
+		var mine []queueEntry
+		for _, e := range entries {
+			waiting[e.Type]++
+			if e.UID == cu.ID {
+				mine = append(mine, e)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Entries": mine, "Waiting": waiting})
+	}
+}
+
+// matchmakingHandler attempts to match the queued users of all game types.
+// Intended to be periodically invoked by App Engine cron, so users unmatched when queued are matched
+// as other users join the queue of other instances of the service.
+func (cl *GameClient[GT, G]) matchmakingHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if err := cl.requireCron(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		entries, err := cl.listQueue(ctx, NoType)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var types []Type
+		for _, e := range entries {
+			if !slices.Contains(types, e.Type) {
+				types = append(types, e.Type)
+			}
+		}
+
+		var matched int
+		for _, t := range types {
+			gs, err := cl.match(ctx, t)
+			if err != nil {
+				Warnf(ctx, "unable to match queue for %s: %v", t, err)
+			}
+			matched += len(gs)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Matched": matched})
+	}
+}
+
+// listQueue returns the entries queued for the game type, oldest first.
+// NoType returns the entries of all game types.
+func (cl *GameClient[GT, G]) listQueue(ctx context.Context, t Type) ([]queueEntry, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	docs, err := cl.store.ListQueue(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]queueEntry, len(docs))
+	for i, doc := range docs {
+		if err := doc.DataTo(&entries[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(entries, func(e1, e2 queueEntry) int { return e1.CreatedAt.Compare(e2.CreatedAt) })
+	return entries, nil
+}
+
+// match groups compatible entries queued for the game type and starts a game for each group.
+// Entries are matched oldest first, with the oldest unmatched entry seeking the largest group it accepts.
+func (cl *GameClient[GT, G]) match(ctx *gin.Context, t Type) ([]G, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	entries, err := cl.listQueue(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]bool)
+	var gs []G
+	for i, e := range entries {
+		if matched[e.id()] {
+			continue
+		}
+
+		group := findGroup(e, entries[i+1:], matched)
+		if group == nil {
+			continue
+		}
+
+		g, err := cl.startMatch(ctx, group)
+		switch {
+		case errors.Is(err, errQueueChanged):
+			continue
+		case err != nil:
+			return gs, err
+		}
+
+		for _, e := range group {
+			matched[e.id()] = true
+		}
+		gs = append(gs, g)
+	}
+	return gs, nil
+}
+
+// findGroup returns the largest group of entries, including e, acceptable to each entry of the group.
+// Returns nil if no such group exists.
+func findGroup(e queueEntry, candidates []queueEntry, matched map[string]bool) []queueEntry {
+	for n := e.MaxPlayers; n >= e.MinPlayers; n-- {
+		group := []queueEntry{e}
+		for _, c := range candidates {
+			if len(group) == n {
+				break
+			}
+
+			if matched[c.id()] || !c.accepts(n) || c.OptString != e.OptString {
+				continue
+			}
+
+			if slices.ContainsFunc(group, func(o queueEntry) bool {
+				return !o.acceptsRating(c, n) || !c.acceptsRating(o, n)
+			}) {
+				continue
+			}
+			group = append(group, c)
+		}
+
+		if len(group) == n {
+			return group
+		}
+	}
+	return nil
+}
+
+// startMatch starts a game for the group of entries and removes the entries from the queue.
+// Returns an error wrapping errQueueChanged if an entry of the group is no longer queued.
+func (cl *GameClient[GT, G]) startMatch(ctx *gin.Context, group []queueEntry) (G, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	h := G(new(GT)).InvitationHeader(ctx)
+	h.setID(newDocID())
+	h.Type = group[0].Type
+	h.NumPlayers = len(group)
+	h.OptString = group[0].OptString
+	h.Status = Recruiting
+	h.addCreator(group[0].user())
+	for _, e := range group {
+		h.addUser(e.user())
+	}
+	now := timestamppb.Now()
+	h.CreatedAt, h.UpdatedAt = now, now
+
+	g, _, err := cl.startGame(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscriptions are updated prior to saving the game, so the tokens are available upon delivery of the notification
+	for _, e := range group {
+		if err := cl.updateSubs(ctx, g.id(), e.Token, e.UID); err != nil {
+			Warnf(ctx, "attempted to update sub: %q: %v", e.Token, err)
+		}
+	}
+
+	n := startedNotification(g)
+	n.Data["Match"] = true
+
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		for _, e := range group {
+			var queued queueEntry
+			if err := tx.GetQueueEntry(e.id(), &queued); errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%s: %w", e.id(), errQueueChanged)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if err := cl.txSaveStarted(ctx, tx, g, group[0].UID, n, turnNotification(g, g.header().CPIDS)); err != nil {
+			return err
+		}
+
+		for _, e := range group {
+			if err := tx.DeleteQueueEntry(e.id()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	cl.kickOutbox()
+	return g, nil
+}
@@ -0,0 +1,956 @@
+package sn
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elliotchance/pie/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// memStore implements Store in memory.
+// Documents are stored as JSON, which mirrors the encoding used by DeepCopy.
+// Primarily intended for testing and for running services locally without Firestore.
+type memStore struct {
+	mu    sync.Mutex
+	docs  map[string]memDoc
+	clock int64
+}
+
+// memDoc provides a stored document and the version of its last write.
+// A nil data value represents a deleted document.
+type memDoc struct {
+	data    []byte
+	version int64
+}
+
+// NewMemoryStore returns a Store that keeps all documents in memory.
+func NewMemoryStore() Store {
+	return &memStore{docs: make(map[string]memDoc)}
+}
+
+const maxMemTxAttempts = 5
+
+func (s *memStore) get(p string, dst any) error {
+	s.mu.Lock()
+	doc, found := s.docs[p]
+	s.mu.Unlock()
+
+	if !found || doc.data == nil {
+		return fmt.Errorf("%s: %w", p, ErrNotFound)
+	}
+	return json.Unmarshal(doc.data, dst)
+}
+
+func (s *memStore) set(p string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(p, data)
+	return nil
+}
+
+func (s *memStore) delete(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(p, nil)
+}
+
+// write stores data at path p and must only be called while holding s.mu
+func (s *memStore) write(p string, data []byte) {
+	s.clock++
+	s.docs[p] = memDoc{data: data, version: s.clock}
+}
+
+// version returns version of document at path p and must only be called while holding s.mu
+func (s *memStore) version(p string) int64 {
+	return s.docs[p].version
+}
+
+func (s *memStore) exists(p string) bool {
+	doc, found := s.docs[p]
+	return found && doc.data != nil
+}
+
+// list returns the documents of the collection at path p, ordered by document id
+func (s *memStore) list(p string) []Doc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var docs []Doc
+	prefix := p + "/"
+	for k, doc := range s.docs {
+		id, found := strings.CutPrefix(k, prefix)
+		if !found || doc.data == nil || strings.Contains(id, "/") {
+			continue
+		}
+		docs = append(docs, memSnap{id: id, data: doc.data})
+	}
+
+	slices.SortFunc(docs, func(d1, d2 Doc) int { return strings.Compare(d1.ID(), d2.ID()) })
+	return docs
+}
+
+// memSnap implements Doc interface for documents of memStore
+type memSnap struct {
+	id   string
+	data []byte
+}
+
+// ID implements Doc interface
+func (d memSnap) ID() string {
+	return d.id
+}
+
+// DataTo implements Doc interface
+func (d memSnap) DataTo(dst any) error {
+	return json.Unmarshal(d.data, dst)
+}
+
+func gamePath(gid string) string {
+	return path.Join("Game", gid)
+}
+
+func revPath(gid string, rev Rev) string {
+	return path.Join(gamePath(gid), "Rev", rev.toString())
+}
+
+func cachedPath(gid string, uid UID, rev Rev) string {
+	return path.Join(gamePath(gid), "CacheFor", uid.toString(), "Rev", rev.toString())
+}
+
+func viewPath(gid string, uid UID) string {
+	return path.Join(gamePath(gid), "For", uid.toString())
+}
+
+func messagePath(gid string, mid string) string {
+	return path.Join(gamePath(gid), "Messages", mid)
+}
+
+func messagesPath(gid string) string {
+	return path.Join(gamePath(gid), "Messages")
+}
+
+func whispersPath(gid string) string {
+	return path.Join(gamePath(gid), "Whispers")
+}
+
+func whisperPath(gid string, mid string) string {
+	return path.Join(whispersPath(gid), mid)
+}
+
+func idempotencyPath(gid string, id string) string {
+	return path.Join(gamePath(gid), "Idempotency", id)
+}
+
+func chatModerationPath(gid string) string {
+	return path.Join("ChatModeration", gid)
+}
+
+func indexPath(id string) string {
+	return path.Join("Index", id)
+}
+
+func stackPath(gid string, uid UID) string {
+	return path.Join("Stack", gid, "For", uid.toString())
+}
+
+func invitationPath(id string) string {
+	return path.Join("Invitation", id)
+}
+
+func hashPath(id string) string {
+	return path.Join(invitationPath(id), "Hash", "hash")
+}
+
+func tournamentPath(id string) string {
+	return path.Join("Tournament", id)
+}
+
+func queuePath(id string) string {
+	return path.Join("Queue", id)
+}
+
+func outboxPath(id string) string {
+	return path.Join("Outbox", id)
+}
+
+func digestPath(uid UID) string {
+	return path.Join("Digest", uid.toString())
+}
+
+func userPath(uid UID) string {
+	return path.Join("User", uid.toString())
+}
+
+func preferencesPath(uid UID) string {
+	return path.Join("Preferences", uid.toString())
+}
+
+func ratingPath(id string) string {
+	return path.Join("Rating", id)
+}
+
+func eloPath(uid UID) string {
+	return path.Join("Elo", uid.toString())
+}
+
+func eloHistoryPath(uid UID, id string) string {
+	return path.Join(eloPath(uid), "History", id)
+}
+
+func ustatPath(uid UID) string {
+	return path.Join("UStat", uid.toString())
+}
+
+func subPath(gid string, uid UID) string {
+	return path.Join(gamePath(gid), "Sub", uid.toString())
+}
+
+func subInvPath(id string, uid UID) string {
+	return path.Join(invitationPath(id), "Sub", uid.toString())
+}
+
+// RunTransaction implements Store interface.
+// Transactions are optimistic. If a document read by the transaction is written
+// before the transaction commits, the transaction is retried.
+func (s *memStore) RunTransaction(ctx context.Context, f func(context.Context, Tx) error) error {
+	for range maxMemTxAttempts {
+		tx := &memTx{store: s, reads: make(map[string]int64)}
+		if err := f(ctx, tx); err != nil {
+			return err
+		}
+
+		committed, err := tx.commit()
+		if err != nil {
+			return err
+		}
+
+		if committed {
+			return nil
+		}
+	}
+	return fmt.Errorf("transaction aborted after %d attempts due to contention", maxMemTxAttempts)
+}
+
+// GetRev implements Store interface
+func (s *memStore) GetRev(_ context.Context, gid string, rev Rev, dst any) error {
+	return s.get(revPath(gid, rev), dst)
+}
+
+// GetCached implements Store interface
+func (s *memStore) GetCached(_ context.Context, gid string, uid UID, rev Rev, dst any) error {
+	return s.get(cachedPath(gid, uid, rev), dst)
+}
+
+// GetStack implements Store interface
+func (s *memStore) GetStack(_ context.Context, gid string, uid UID, dst *Stack) error {
+	return s.get(stackPath(gid, uid), dst)
+}
+
+// GetIndex implements Store interface
+func (s *memStore) GetIndex(_ context.Context, id string, dst any) error {
+	return s.get(indexPath(id), dst)
+}
+
+// ListIndexes implements Store interface
+func (s *memStore) ListIndexes(_ context.Context, q IndexQuery) ([]Doc, error) {
+	var docs []Doc
+	updated := make(map[string]time.Time)
+	for _, doc := range s.list("Index") {
+		var i index
+		if err := doc.DataTo(&i); err != nil {
+			return nil, err
+		}
+
+		if q.Status != NoStatus && i.Status != q.Status {
+			continue
+		}
+
+		if !q.CheckBy.IsZero() && (i.TurnCheckAt == nil || i.TurnCheckAt.AsTime().After(q.CheckBy)) {
+			continue
+		}
+
+		if q.Type != NoType && i.Type != q.Type {
+			continue
+		}
+
+		// As with fsStore, UserID is ignored if CurrentUserID is set
+		switch {
+		case q.CurrentUserID != 0:
+			if !slices.Contains(i.CPUserIDS, q.CurrentUserID) {
+				continue
+			}
+		case q.UserID != 0:
+			if !slices.Contains(i.UserIDS, q.UserID) {
+				continue
+			}
+		}
+
+		if q.SortByUpdatedAt && q.After != nil && compareIndex(i.UpdatedAt.AsTime(), doc.ID(), *q.After, q.Desc) <= 0 {
+			continue
+		}
+		docs = append(docs, doc)
+		updated[doc.ID()] = i.UpdatedAt.AsTime()
+	}
+
+	if q.SortByUpdatedAt {
+		slices.SortStableFunc(docs, func(d1, d2 Doc) int {
+			return compareIndex(updated[d1.ID()], d1.ID(), IndexCursor{updated[d2.ID()], d2.ID()}, q.Desc)
+		})
+	}
+
+	if q.Limit > 0 {
+		docs = docs[:min(q.Limit, len(docs))]
+	}
+	return docs, nil
+}
+
+// compareIndex compares the position of the indexed game, updated at t and having the id, to the cursor
+// in ascending, or if desc, descending order of UpdatedAt and then id.
+func compareIndex(t time.Time, id string, c IndexCursor, desc bool) int {
+	r := t.Compare(c.UpdatedAt)
+	if r == 0 {
+		r = strings.Compare(id, c.ID)
+	}
+	if desc {
+		return -r
+	}
+	return r
+}
+
+// GetInvitation implements Store interface
+func (s *memStore) GetInvitation(_ context.Context, id string, dst any) error {
+	return s.get(invitationPath(id), dst)
+}
+
+// SetInvitation implements Store interface
+func (s *memStore) SetInvitation(_ context.Context, id string, inv any) error {
+	return s.set(invitationPath(id), inv)
+}
+
+// GetHash implements Store interface
+func (s *memStore) GetHash(_ context.Context, id string) ([]byte, error) {
+	var obj struct{ Hash []byte }
+	if err := s.get(hashPath(id), &obj); err != nil {
+		return nil, err
+	}
+	return obj.Hash, nil
+}
+
+// GetTournament implements Store interface
+func (s *memStore) GetTournament(_ context.Context, id string, dst any) error {
+	return s.get(tournamentPath(id), dst)
+}
+
+// ListQueue implements Store interface
+func (s *memStore) ListQueue(_ context.Context, t Type) ([]Doc, error) {
+	var docs []Doc
+	for _, doc := range s.list("Queue") {
+		var e struct{ Type Type }
+		if err := doc.DataTo(&e); err != nil {
+			return nil, err
+		}
+
+		if t == NoType || e.Type == t {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// ListOutbox implements Store interface
+func (s *memStore) ListOutbox(_ context.Context, due time.Time, limit int) ([]Doc, error) {
+	type entry struct {
+		doc           Doc
+		NextAttemptAt time.Time
+		DeadLettered  bool
+	}
+
+	var entries []entry
+	for _, doc := range s.list("Outbox") {
+		e := entry{doc: doc}
+		if err := doc.DataTo(&e); err != nil {
+			return nil, err
+		}
+
+		if !e.DeadLettered && !e.NextAttemptAt.After(due) {
+			entries = append(entries, e)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(e1, e2 entry) int { return e1.NextAttemptAt.Compare(e2.NextAttemptAt) })
+	docs := pie.Map(entries, func(e entry) Doc { return e.doc })
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// ListExpiredInvitations implements Store interface
+func (s *memStore) ListExpiredInvitations(_ context.Context, due time.Time) ([]Doc, error) {
+	var docs []Doc
+	for _, doc := range s.list("Invitation") {
+		var inv struct{ ExpiresAt *timestamppb.Timestamp }
+		if err := doc.DataTo(&inv); err != nil {
+			return nil, err
+		}
+
+		if inv.ExpiresAt != nil && !inv.ExpiresAt.AsTime().After(due) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// ListInvitations implements Store interface
+func (s *memStore) ListInvitations(_ context.Context, q InvitationQuery) ([]Doc, error) {
+	type entry struct {
+		doc Doc
+		inv invitation
+	}
+
+	var es []entry
+	for _, doc := range s.list("Invitation") {
+		var inv invitation
+		if err := doc.DataTo(&inv); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case q.Status != NoStatus && inv.Status != q.Status:
+		case q.Type != NoType && inv.Type != q.Type:
+		case q.NumPlayers != 0 && inv.NumPlayers != q.NumPlayers:
+		case q.Private != nil && inv.Private != *q.Private:
+		case q.CreatorID != 0 && inv.CreatorID != q.CreatorID:
+		case q.UserID != 0 && !slices.Contains(inv.UserIDS, q.UserID):
+		default:
+			es = append(es, entry{doc, inv})
+		}
+	}
+
+	slices.SortStableFunc(es, func(e1, e2 entry) int {
+		return e2.inv.CreatedAt.AsTime().Compare(e1.inv.CreatedAt.AsTime())
+	})
+
+	docs := make([]Doc, 0, len(es))
+	for _, e := range es {
+		docs = append(docs, e.doc)
+	}
+
+	docs = docs[min(q.Offset, len(docs)):]
+	if q.Limit > 0 {
+		docs = docs[:min(q.Limit, len(docs))]
+	}
+	return docs, nil
+}
+
+// ListInvites implements Store interface
+func (s *memStore) ListInvites(_ context.Context, uid UID) ([]Doc, error) {
+	var docs []Doc
+	for _, doc := range s.list("Invitation") {
+		var inv struct{ InviteeIDS []UID }
+		if err := doc.DataTo(&inv); err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(inv.InviteeIDS, uid) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// ListDigests implements Store interface
+func (s *memStore) ListDigests(_ context.Context, due time.Time) ([]Doc, error) {
+	var docs []Doc
+	for _, doc := range s.list("Digest") {
+		var d struct{ SendAt time.Time }
+		if err := doc.DataTo(&d); err != nil {
+			return nil, err
+		}
+
+		if !d.SendAt.After(due) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// GetPreferences implements Store interface
+func (s *memStore) GetPreferences(_ context.Context, uid UID, dst any) error {
+	return s.get(preferencesPath(uid), dst)
+}
+
+// SetPreferences implements Store interface
+func (s *memStore) SetPreferences(_ context.Context, uid UID, p any) error {
+	return s.set(preferencesPath(uid), p)
+}
+
+// GetUser implements Store interface
+func (s *memStore) GetUser(_ context.Context, uid UID, dst any) error {
+	return s.get(userPath(uid), dst)
+}
+
+// GetRating implements Store interface
+func (s *memStore) GetRating(_ context.Context, id string, dst any) error {
+	return s.get(ratingPath(id), dst)
+}
+
+// GetLegacyElo implements Store interface
+func (s *memStore) GetLegacyElo(_ context.Context, uid UID, dst any) error {
+	return s.get(eloPath(uid), dst)
+}
+
+// ListRatings implements Store interface
+func (s *memStore) ListRatings(_ context.Context, q RatingQuery) ([]Doc, error) {
+	return listRatings(s.list("Rating"), q)
+}
+
+// ListRatingHistory implements Store interface
+func (s *memStore) ListRatingHistory(_ context.Context, uid UID, q RatingQuery) ([]Doc, error) {
+	return listRatings(s.list(path.Join(eloPath(uid), "History")), q)
+}
+
+func listRatings(docs []Doc, q RatingQuery) ([]Doc, error) {
+	type ratingDoc struct {
+		doc    Doc
+		rating Rating
+	}
+
+	var rds []ratingDoc
+	for _, doc := range docs {
+		var r Rating
+		if err := doc.DataTo(&r); err != nil {
+			return nil, err
+		}
+
+		if (q.System != "" && r.System != q.System) ||
+			(q.Type != NoType && r.Type != q.Type) ||
+			(q.NumPlayers != 0 && r.NumPlayers != q.NumPlayers) {
+			continue
+		}
+		rds = append(rds, ratingDoc{doc: doc, rating: r})
+	}
+
+	if q.OrderBy != "" {
+		slices.SortStableFunc(rds, func(rd1, rd2 ratingDoc) int {
+			c := compareRatings(rd1.rating, rd2.rating, q.OrderBy)
+			if q.Desc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	rds = rds[min(q.Offset, len(rds)):]
+	if q.Limit > 0 {
+		rds = rds[:min(q.Limit, len(rds))]
+	}
+	return pie.Map(rds, func(rd ratingDoc) Doc { return rd.doc }), nil
+}
+
+// compareRatings compares the named field of the ratings
+func compareRatings(r1, r2 Rating, field string) int {
+	switch field {
+	case "Rating":
+		return cmp.Compare(r1.Rating, r2.Rating)
+	case "Played":
+		return cmp.Compare(r1.Played, r2.Played)
+	case "Won":
+		return cmp.Compare(r1.Won, r2.Won)
+	case "WinPercentage":
+		return cmp.Compare(r1.WinPercentage, r2.WinPercentage)
+	case "FinishAvg":
+		return cmp.Compare(r1.FinishAvg, r2.FinishAvg)
+	case "UpdatedAt":
+		return r1.UpdatedAt.Compare(r2.UpdatedAt)
+	default:
+		return 0
+	}
+}
+
+// GetUStat implements Store interface
+func (s *memStore) GetUStat(_ context.Context, uid UID, dst any) error {
+	return s.get(ustatPath(uid), dst)
+}
+
+// GetSubs implements Store interface
+func (s *memStore) GetSubs(_ context.Context, gid string, uid UID, dst any) error {
+	return s.get(subPath(gid, uid), dst)
+}
+
+// SetSubs implements Store interface
+func (s *memStore) SetSubs(_ context.Context, gid string, uid UID, subs any) error {
+	return s.set(subPath(gid, uid), subs)
+}
+
+// DeleteSubs implements Store interface
+func (s *memStore) DeleteSubs(_ context.Context, gid string, uid UID) error {
+	s.delete(subPath(gid, uid))
+	return nil
+}
+
+// SetInvitationSubs implements Store interface
+func (s *memStore) SetInvitationSubs(_ context.Context, id string, uid UID, subs any) error {
+	return s.set(subInvPath(id, uid), subs)
+}
+
+// AddMessage implements Store interface
+func (s *memStore) AddMessage(_ context.Context, gid string, m any) (string, error) {
+	mid := newDocID()
+	return mid, s.set(messagePath(gid, mid), m)
+}
+
+// MarkRead implements Store interface
+func (s *memStore) MarkRead(_ context.Context, gid string, mid string, uid UID) error {
+	p := messagePath(gid, mid)
+
+	var m Message
+	if err := s.get(p, &m); err != nil {
+		return err
+	}
+
+	if slices.Contains(m.Read, uid) {
+		return nil
+	}
+	m.Read = append(m.Read, uid)
+	return s.set(p, m)
+}
+
+// ListMessages implements Store interface
+func (s *memStore) ListMessages(_ context.Context, gid string) ([]Doc, error) {
+	return listMessages(s.list(messagesPath(gid)), 0)
+}
+
+// AddWhisper implements Store interface
+func (s *memStore) AddWhisper(_ context.Context, gid string, m any) (string, error) {
+	mid := newDocID()
+	return mid, s.set(whisperPath(gid, mid), m)
+}
+
+// ListWhispers implements Store interface
+func (s *memStore) ListWhispers(_ context.Context, gid string, uid UID) ([]Doc, error) {
+	return listMessages(s.list(whispersPath(gid)), uid)
+}
+
+// listMessages returns the message documents in order of creation.
+// If uid is not zero, only documents having the user in their Audience are returned.
+func listMessages(docs []Doc, uid UID) ([]Doc, error) {
+	type entry struct {
+		doc       Doc
+		Audience  []UID
+		CreatedAt *timestamppb.Timestamp
+	}
+
+	var entries []entry
+	for _, doc := range docs {
+		e := entry{doc: doc}
+		if err := doc.DataTo(&e); err != nil {
+			return nil, err
+		}
+
+		if uid == 0 || slices.Contains(e.Audience, uid) {
+			entries = append(entries, e)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(e1, e2 entry) int {
+		return e1.CreatedAt.AsTime().Compare(e2.CreatedAt.AsTime())
+	})
+	return pie.Map(entries, func(e entry) Doc { return e.doc }), nil
+}
+
+// GetChatModeration implements Store interface
+func (s *memStore) GetChatModeration(_ context.Context, gid string, dst any) error {
+	return s.get(chatModerationPath(gid), dst)
+}
+
+// SetIdempotencyRecord implements Store interface
+func (s *memStore) SetIdempotencyRecord(_ context.Context, gid string, id string, rec any) error {
+	return s.set(idempotencyPath(gid, id), rec)
+}
+
+// DeleteIdempotencyRecord implements Store interface
+func (s *memStore) DeleteIdempotencyRecord(_ context.Context, gid string, id string) error {
+	s.delete(idempotencyPath(gid, id))
+	return nil
+}
+
+// memTx implements Tx for memStore
+type memTx struct {
+	store  *memStore
+	reads  map[string]int64
+	writes []memWrite
+}
+
+// memWrite provides a pending write of a transaction.
+type memWrite struct {
+	path   string
+	data   []byte
+	create bool
+}
+
+func (t *memTx) get(p string, dst any) error {
+	t.store.mu.Lock()
+	doc := t.store.docs[p]
+	t.store.mu.Unlock()
+
+	t.reads[p] = doc.version
+	if doc.data == nil {
+		return fmt.Errorf("%s: %w", p, ErrNotFound)
+	}
+	return json.Unmarshal(doc.data, dst)
+}
+
+func (t *memTx) set(p string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.writes = append(t.writes, memWrite{path: p, data: data})
+	return nil
+}
+
+func (t *memTx) create(p string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.writes = append(t.writes, memWrite{path: p, data: data, create: true})
+	return nil
+}
+
+func (t *memTx) delete(p string) error {
+	t.writes = append(t.writes, memWrite{path: p})
+	return nil
+}
+
+// commit applies writes of transaction, provided no document read by the transaction has since changed.
+// Returns false, if transaction should be retried.
+func (t *memTx) commit() (bool, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	for p, version := range t.reads {
+		if t.store.version(p) != version {
+			return false, nil
+		}
+	}
+
+	for _, w := range t.writes {
+		if w.create && t.store.exists(w.path) {
+			return false, fmt.Errorf("%s already exists", w.path)
+		}
+	}
+
+	for _, w := range t.writes {
+		t.store.write(w.path, w.data)
+	}
+	return true, nil
+}
+
+// GetIndex implements Tx interface
+func (t *memTx) GetIndex(id string, dst any) error {
+	return t.get(indexPath(id), dst)
+}
+
+// SetRev implements Tx interface
+func (t *memTx) SetRev(gid string, rev Rev, g any) error {
+	return t.set(revPath(gid, rev), g)
+}
+
+// SetCached implements Tx interface
+func (t *memTx) SetCached(gid string, uid UID, rev Rev, g any) error {
+	return t.set(cachedPath(gid, uid, rev), g)
+}
+
+// DeleteCached implements Tx interface
+func (t *memTx) DeleteCached(gid string, uid UID, rev Rev) error {
+	return t.delete(cachedPath(gid, uid, rev))
+}
+
+// GetStack implements Tx interface
+func (t *memTx) GetStack(gid string, uid UID, dst *Stack) error {
+	return t.get(stackPath(gid, uid), dst)
+}
+
+// SetStack implements Tx interface
+func (t *memTx) SetStack(gid string, uid UID, stack *Stack) error {
+	return t.set(stackPath(gid, uid), stack)
+}
+
+// SetView implements Tx interface
+func (t *memTx) SetView(gid string, uid UID, v any) error {
+	return t.set(viewPath(gid, uid), v)
+}
+
+// SetIndex implements Tx interface
+func (t *memTx) SetIndex(id string, i any) error {
+	return t.set(indexPath(id), i)
+}
+
+// GetInvitation implements Tx interface
+func (t *memTx) GetInvitation(id string, dst any) error {
+	return t.get(invitationPath(id), dst)
+}
+
+// SetInvitation implements Tx interface
+func (t *memTx) SetInvitation(id string, inv any) error {
+	return t.set(invitationPath(id), inv)
+}
+
+// CreateInvitation implements Tx interface
+func (t *memTx) CreateInvitation(inv any) (string, error) {
+	id := newDocID()
+	return id, t.create(invitationPath(id), inv)
+}
+
+// DeleteInvitation implements Tx interface
+func (t *memTx) DeleteInvitation(id string) error {
+	return t.delete(invitationPath(id))
+}
+
+// CreateHash implements Tx interface
+func (t *memTx) CreateHash(id string, hash []byte) error {
+	return t.create(hashPath(id), H{"Hash": hash})
+}
+
+// SetHash implements Tx interface
+func (t *memTx) SetHash(id string, hash []byte) error {
+	return t.set(hashPath(id), H{"Hash": hash})
+}
+
+// DeleteHash implements Tx interface
+func (t *memTx) DeleteHash(id string) error {
+	return t.delete(hashPath(id))
+}
+
+// GetTournament implements Tx interface
+func (t *memTx) GetTournament(id string, dst any) error {
+	return t.get(tournamentPath(id), dst)
+}
+
+// CreateTournament implements Tx interface
+func (t *memTx) CreateTournament(tour any) (string, error) {
+	id := newDocID()
+	return id, t.create(tournamentPath(id), tour)
+}
+
+// SetTournament implements Tx interface
+func (t *memTx) SetTournament(id string, tour any) error {
+	return t.set(tournamentPath(id), tour)
+}
+
+// GetQueueEntry implements Tx interface
+func (t *memTx) GetQueueEntry(id string, dst any) error {
+	return t.get(queuePath(id), dst)
+}
+
+// SetQueueEntry implements Tx interface
+func (t *memTx) SetQueueEntry(id string, e any) error {
+	return t.set(queuePath(id), e)
+}
+
+// DeleteQueueEntry implements Tx interface
+func (t *memTx) DeleteQueueEntry(id string) error {
+	return t.delete(queuePath(id))
+}
+
+// GetOutboxEntry implements Tx interface
+func (t *memTx) GetOutboxEntry(id string, dst any) error {
+	return t.get(outboxPath(id), dst)
+}
+
+// SetOutboxEntry implements Tx interface
+func (t *memTx) SetOutboxEntry(id string, n any) error {
+	return t.set(outboxPath(id), n)
+}
+
+// DeleteOutboxEntry implements Tx interface
+func (t *memTx) DeleteOutboxEntry(id string) error {
+	return t.delete(outboxPath(id))
+}
+
+// GetIdempotencyRecord implements Tx interface
+func (t *memTx) GetIdempotencyRecord(gid string, id string, dst any) error {
+	return t.get(idempotencyPath(gid, id), dst)
+}
+
+// SetIdempotencyRecord implements Tx interface
+func (t *memTx) SetIdempotencyRecord(gid string, id string, rec any) error {
+	return t.set(idempotencyPath(gid, id), rec)
+}
+
+// GetDigest implements Tx interface
+func (t *memTx) GetDigest(uid UID, dst any) error {
+	return t.get(digestPath(uid), dst)
+}
+
+// SetDigest implements Tx interface
+func (t *memTx) SetDigest(uid UID, d any) error {
+	return t.set(digestPath(uid), d)
+}
+
+// DeleteDigest implements Tx interface
+func (t *memTx) DeleteDigest(uid UID) error {
+	return t.delete(digestPath(uid))
+}
+
+// GetMessage implements Tx interface
+func (t *memTx) GetMessage(gid string, mid string, dst any) error {
+	return t.get(messagePath(gid, mid), dst)
+}
+
+// SetMessage implements Tx interface
+func (t *memTx) SetMessage(gid string, mid string, m any) error {
+	return t.set(messagePath(gid, mid), m)
+}
+
+// GetWhisper implements Tx interface
+func (t *memTx) GetWhisper(gid string, mid string, dst any) error {
+	return t.get(whisperPath(gid, mid), dst)
+}
+
+// SetWhisper implements Tx interface
+func (t *memTx) SetWhisper(gid string, mid string, m any) error {
+	return t.set(whisperPath(gid, mid), m)
+}
+
+// GetChatModeration implements Tx interface
+func (t *memTx) GetChatModeration(gid string, dst any) error {
+	return t.get(chatModerationPath(gid), dst)
+}
+
+// SetChatModeration implements Tx interface
+func (t *memTx) SetChatModeration(gid string, m any) error {
+	return t.set(chatModerationPath(gid), m)
+}
+
+// SetRating implements Tx interface
+func (t *memTx) SetRating(id string, r any) error {
+	return t.set(ratingPath(id), r)
+}
+
+// AddEloHistory implements Tx interface
+func (t *memTx) AddEloHistory(uid UID, e any) error {
+	return t.create(eloHistoryPath(uid, newDocID()), e)
+}
+
+// SetUStat implements Tx interface
+func (t *memTx) SetUStat(uid UID, stat any) error {
+	return t.set(ustatPath(uid), stat)
+}
@@ -0,0 +1,180 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testGID = "test-game"
+
+	// testUIDHeader identifies the user logged in by testLogin
+	testUIDHeader = "X-Test-UID"
+)
+
+type testState struct {
+	Count int
+}
+
+// testGame provides a minimal game for exercising game handlers against a memStore
+type testGame struct {
+	Game[testState, Player, *Player]
+}
+
+func (g *testGame) Start(_ context.Context, h Header) (PID, error) {
+	return g.Game.Start(h), nil
+}
+
+func (g *testGame) Views() ([]UID, []*testGame) {
+	return []UID{0}, []*testGame{g.ViewFor(0)}
+}
+
+func (g *testGame) ViewFor(_ UID) *testGame {
+	return DeepCopy(g)
+}
+
+type testClient = GameClient[testGame, *testGame]
+
+// newTestClient returns a game client backed by a memStore, whose router logs in the user of testUIDHeader
+func newTestClient(t *testing.T, opts ...Option) *testClient {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	cl := &testClient{Client: defaultClient()}
+	for _, opt := range opts {
+		cl.Client = opt(cl.Client)
+	}
+	cl.store = NewMemoryStore()
+	cl.Router = gin.New()
+	cl.Router.Use(
+		sessions.Sessions("test-session", cookie.NewStore([]byte("test-session-secret"))),
+		cl.requestContext(),
+		testLogin(cl),
+	)
+	return cl
+}
+
+func testLogin(cl *testClient) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// requests having a uid of zero are not logged in
+		id, err := strconv.ParseInt(ctx.GetHeader(testUIDHeader), 10, 64)
+		if err != nil || id <= 0 {
+			return
+		}
+		u := &User{ID: UID(id), userData: userData{Name: "user" + ctx.GetHeader(testUIDHeader)}}
+		cl.setSessionToken(ctx, tokenFrom(u, ""))
+	}
+}
+
+// startTestGame starts and saves a game having the users as players
+func startTestGame(t *testing.T, cl *testClient, uids ...UID) {
+	t.Helper()
+
+	ctx := context.Background()
+	g, _, err := cl.startGame(ctx, Header{Type: "test", NumPlayers: len(uids), UserIDS: uids})
+	if err != nil {
+		t.Fatalf("startGame: %v", err)
+	}
+	g.setID(testGID)
+
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return cl.txSaveStarted(ctx, tx, g, 0)
+	}); err != nil {
+		t.Fatalf("txSaveStarted: %v", err)
+	}
+}
+
+// serve performs the request as the user and returns the recorded response
+func serve(cl *testClient, method, path string, uid UID, body string, header ...string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set(testUIDHeader, strconv.FormatInt(int64(uid), 10))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	cl.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMemStoreTransactionRetriesContention(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	var attempts int
+	err := s.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		attempts++
+		var stack Stack
+		if err := tx.GetStack(testGID, 1, &stack); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		if attempts == 1 {
+			// a concurrent write to a document read by the transaction aborts the attempt
+			if err := s.RunTransaction(ctx, func(_ context.Context, tx2 Tx) error {
+				return tx2.SetStack(testGID, 1, &Stack{Current: 1})
+			}); err != nil {
+				return err
+			}
+		}
+
+		stack.Current++
+		return tx.SetStack(testGID, 1, &stack)
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	var stack Stack
+	if err := s.GetStack(ctx, testGID, 1, &stack); err != nil {
+		t.Fatal(err)
+	}
+	if stack.Current != 2 {
+		t.Errorf("Current = %d, want 2", stack.Current)
+	}
+}
+
+func TestMemStoreTransactionDiscardsWritesOnError(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	errAbort := errors.New("abort")
+	err := s.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		if err := tx.SetStack(testGID, 1, &Stack{Current: 1}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("RunTransaction = %v, want %v", err, errAbort)
+	}
+
+	var stack Stack
+	if err := s.GetStack(ctx, testGID, 1, &stack); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetStack = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemStoreReturnsNotFound(t *testing.T) {
+	var i index
+	if err := NewMemoryStore().GetIndex(context.Background(), testGID, &i); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetIndex = %v, want ErrNotFound", err)
+	}
+}
@@ -1,14 +1,36 @@
 package sn
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
-	"cloud.google.com/go/firestore"
+	"github.com/elliotchance/pie/v2"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+const (
+	// maxMessageLength provides the maximum number of characters of the text of a message
+	maxMessageLength = 2000
+
+	// maxReactions provides the maximum number of distinct emoji with which a message may be reacted to
+	maxReactions = 20
+
+	// maxEmojiLength provides the maximum number of code points of an emoji reaction,
+	// thereby permitting modifier and zero width joiner sequences
+	maxEmojiLength = 8
+
+	defaultMessageLimit = 50
+	maxMessageLimit     = 200
+)
+
 // Message represents a chat message
 type Message struct {
 	Text             string
@@ -17,13 +39,28 @@ type Message struct {
 	CreatorEmailHash string
 	CreatorGravType  string
 	Read             []UID
-	CreatedAt        *timestamppb.Timestamp
-	UpdatedAt        *timestamppb.Timestamp
+	// To provides the players to whom a whisper is addressed. Messages without recipients are public.
+	To []UID
+	// Audience provides the creator and recipients of a whisper, the only users that may read the whisper
+	Audience []UID
+	// Mentions provides the players mentioned, via @name, in the text
+	Mentions []UID
+	// Reactions provides the users that reacted to the message with each emoji
+	Reactions map[string][]UID
+	// EditedAt provides the time of the last edit of the text, if edited
+	EditedAt *timestamppb.Timestamp
+	// Deleted indicates the message was deleted by DeletedBy.
+	// Deleted messages are retained for moderation, but their content is withheld from users other than admins.
+	Deleted   bool
+	DeletedBy UID
+	DeletedAt *timestamppb.Timestamp
+	CreatedAt *timestamppb.Timestamp
+	UpdatedAt *timestamppb.Timestamp
 }
 
 func newMessageFor(m *message, user *User) Message {
 	t := timestamppb.Now()
-	return Message{
+	msg := Message{
 		Text:             m.Text,
 		CreatorID:        user.ID,
 		CreatorName:      user.Name,
@@ -33,6 +70,34 @@ func newMessageFor(m *message, user *User) Message {
 		CreatedAt:        t,
 		UpdatedAt:        t,
 	}
+	if len(m.To) > 0 {
+		msg.To = m.To
+		msg.Audience = append([]UID{user.ID}, m.To...)
+	}
+	return msg
+}
+
+// whisper returns true if the message is private to its Audience
+func (m Message) whisper() bool {
+	return len(m.To) > 0
+}
+
+// visibleTo returns true if the user may read the message
+func (m Message) visibleTo(uid UID) bool {
+	return !m.whisper() || slices.Contains(m.Audience, uid)
+}
+
+// withheld returns the message with its content withheld, if the message was deleted
+func (m Message) withheld() Message {
+	if m.Deleted {
+		m.Text, m.Mentions, m.Reactions = "", nil, nil
+	}
+	return m
+}
+
+// unread returns true if the message is unread by the user
+func (m Message) unread(uid UID) bool {
+	return !m.Deleted && m.CreatorID != uid && m.visibleTo(uid) && !slices.Contains(m.Read, uid)
 }
 
 func (cl *GameClient[GT, G]) updateReadHandler() gin.HandlerFunc {
@@ -43,7 +108,7 @@ func (cl *GameClient[GT, G]) updateReadHandler() gin.HandlerFunc {
 			return
 		}
 
-		read, err := getRead(ctx)
+		read, whispers, err := getRead(ctx)
 		if err != nil {
 			JErr(ctx, err)
 			return
@@ -53,14 +118,44 @@ func (cl *GameClient[GT, G]) updateReadHandler() gin.HandlerFunc {
 			JErr(ctx, err)
 			return
 		}
+
+		if err := cl.updateWhispersRead(ctx, cu.ID, whispers); err != nil {
+			JErr(ctx, err)
+			return
+		}
 		ctx.JSON(http.StatusOK, nil)
 	}
 }
 
 func (cl *GameClient[GT, G]) updateRead(ctx *gin.Context, uid UID, read []string) error {
 	for _, mid := range read {
-		if _, err := cl.messageDocRef(getID(ctx), mid).Update(ctx, []firestore.Update{
-			{Path: "Read", Value: firestore.ArrayUnion(uid)},
+		if err := cl.store.MarkRead(ctx, getID(ctx), mid, uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateWhispersRead marks the whispers read by the user.
+// Unlike public messages, the whisper is read prior to marking, so that only its Audience may mark it read.
+func (cl *GameClient[GT, G]) updateWhispersRead(ctx *gin.Context, uid UID, whispers []string) error {
+	gid := getID(ctx)
+	for _, mid := range whispers {
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			var m Message
+			if err := tx.GetWhisper(gid, mid, &m); err != nil {
+				return err
+			}
+
+			if !m.visibleTo(uid) {
+				return fmt.Errorf("message %q not found: %w", mid, ErrValidation)
+			}
+
+			if slices.Contains(m.Read, uid) {
+				return nil
+			}
+			m.Read = append(m.Read, uid)
+			return tx.SetWhisper(gid, mid, m)
 		}); err != nil {
 			return err
 		}
@@ -68,20 +163,23 @@ func (cl *GameClient[GT, G]) updateRead(ctx *gin.Context, uid UID, read []string
 	return nil
 }
 
-func getRead(ctx *gin.Context) ([]string, error) {
+func getRead(ctx *gin.Context) ([]string, []string, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
 	var obj struct {
-		Read []string
+		Read     []string
+		Whispers []string
 	}
 	err := ctx.ShouldBind(&obj)
-	return obj.Read, err
+	return obj.Read, obj.Whispers, err
 }
 
 type message struct {
 	CreatorID UID
 	Text      string
+	// To, if provided, addresses the message privately to the players
+	To []UID
 }
 
 func getMessage(ctx *gin.Context) (*message, error) {
@@ -95,6 +193,20 @@ func getMessage(ctx *gin.Context) (*message, error) {
 	return obj, nil
 }
 
+// validateText returns the text, trimmed of surrounding white space, if non-empty and at most maxMessageLength characters
+func validateText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return "", fmt.Errorf("message must not be empty: %w", ErrValidation)
+	case utf8.RuneCountInString(text) > maxMessageLength:
+		return "", fmt.Errorf("message must not exceed %d characters: %w", maxMessageLength, ErrValidation)
+	case !utf8.ValidString(text):
+		return "", fmt.Errorf("message must be valid utf-8: %w", ErrValidation)
+	}
+	return text, nil
+}
+
 func (cl *GameClient[GT, G]) addMessageHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
@@ -132,6 +244,19 @@ func (cl *GameClient[GT, G]) validateAddMessage(ctx *gin.Context, cu *User) (*me
 	if m.CreatorID != cu.ID {
 		return nil, fmt.Errorf("invalid creator: %w", ErrValidation)
 	}
+
+	if m.Text, err = validateText(m.Text); err != nil {
+		return nil, err
+	}
+
+	mod, err := cl.getChatModeration(ctx, getID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mod.canPost(cu.ID, time.Now()); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
@@ -139,6 +264,520 @@ func (cl *GameClient[GT, G]) addMessage(ctx *gin.Context, m Message) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	_, err := cl.messagesCollectionRef(getID(ctx)).NewDoc().Create(ctx, m)
-	return err
+	gid := getID(ctx)
+	index, err := cl.getIndex(ctx, gid)
+	if err != nil {
+		return err
+	}
+	m.Mentions = mentionsFor(&index.Header, m)
+
+	var id string
+	if m.whisper() {
+		if err := validateWhisper(&index.Header, m); err != nil {
+			return err
+		}
+		id, err = cl.store.AddWhisper(ctx, gid, m)
+	} else {
+		id, err = cl.store.AddMessage(ctx, gid, m)
+	}
+	if err != nil {
+		return err
+	}
+
+	cl.broker.publish(gameEvent{Kind: MessageEvent, GID: gid, MessageID: id, Message: &m})
+
+	if err := cl.notifyChat(ctx, index, m); err != nil {
+		Warnf(ctx, "unable to notify players of message %s of %s: %v", id, gid, err)
+	}
+	return nil
+}
+
+// notifyChat notifies the players of the game, other than the creator of the message, of the message.
+// Mentioned players are notified of their mention, rather than of the message.
+func (cl *GameClient[GT, G]) notifyChat(ctx *gin.Context, index *index, m Message) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	pids := pie.Filter(index.allPIDS(), func(pid PID) bool {
+		uid := index.UIDFor(pid)
+		return uid != m.CreatorID && m.visibleTo(uid) && !slices.Contains(m.Mentions, uid)
+	})
+	return cl.notify(ctx, chatNotification(ChatMessageEvent, index, m, pids), mentionNotification(index, m, m.Mentions))
+}
+
+// chatNotification returns a notification of the event, concerning the message, to the players of the game
+func chatNotification(event NotificationEvent, index *index, m Message, pids []PID) notification {
+	return eventNotification(event, index.Type, index.id(), recipientsFor(&index.Header, pids), H{
+		"Game":    index.Title,
+		"Sender":  m.CreatorName,
+		"Text":    m.Text,
+		"Whisper": m.whisper(),
+	})
+}
+
+// mentionNotification returns a notification informing the users of their mention in the message
+func mentionNotification(index *index, m Message, uids []UID) notification {
+	pids := pie.Filter(index.allPIDS(), func(pid PID) bool { return slices.Contains(uids, index.UIDFor(pid)) })
+	return chatNotification(MentionEvent, index, m, pids)
+}
+
+// validateWhisper returns an error unless the creator and recipients of the whisper are distinct players of the game
+func validateWhisper(h *Header, m Message) error {
+	if !h.isPlayer(m.CreatorID) {
+		return fmt.Errorf("only players may whisper: %w", ErrValidation)
+	}
+
+	for i, uid := range m.To {
+		switch {
+		case uid == m.CreatorID:
+			return fmt.Errorf("you may not whisper to yourself: %w", ErrValidation)
+		case !h.isPlayer(uid):
+			return fmt.Errorf("user %d is not a player: %w", uid, ErrValidation)
+		case slices.Contains(m.To[:i], uid):
+			return fmt.Errorf("user %d is a duplicate recipient: %w", uid, ErrValidation)
+		}
+	}
+	return nil
+}
+
+// mentionsFor returns the players mentioned in the message that may read the message
+func mentionsFor(h *Header, m Message) []UID {
+	return pie.Filter(mentionsIn(h, m.Text, m.CreatorID), m.visibleTo)
+}
+
+// mentionsIn returns the players of the game, other than the author, mentioned via @name in the text.
+// Names are matched without regard to case.
+func mentionsIn(h *Header, text string, author UID) []UID {
+	text = strings.ToLower(text)
+	var uids []UID
+	for _, pid := range h.allPIDS() {
+		uid := h.UIDFor(pid)
+		if uid != author && mentions(text, "@"+strings.ToLower(h.NameFor(pid))) && !slices.Contains(uids, uid) {
+			uids = append(uids, uid)
+		}
+	}
+	return uids
+}
+
+// mentions returns true if the text contains the mention not immediately followed by a letter or digit,
+// so that a mention of @al is not found in @alice
+func mentions(text, mention string) bool {
+	for i := 0; i < len(text); {
+		j := strings.Index(text[i:], mention)
+		if j < 0 {
+			return false
+		}
+
+		end := i + j + len(mention)
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if end == len(text) || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return true
+		}
+		i += j + 1
+	}
+	return false
+}
+
+// messageRef identifies a message, or whisper, of the game in requests
+type messageRef struct {
+	MessageID string
+	Whisper   bool
+}
+
+// updateMessage updates the message of the game, via update, within a transaction and publishes the updated message.
+// Whispers may only be updated by their Audience, so admins outside the Audience may not moderate whispers.
+func (cl *GameClient[GT, G]) updateMessage(ctx *gin.Context, ref messageRef, cu *User, update func(*Message, *chatModeration) error) (Message, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	gid, mid := getID(ctx), ref.MessageID
+	var m Message
+	err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		m = Message{}
+		get, set := tx.GetMessage, tx.SetMessage
+		if ref.Whisper {
+			get, set = tx.GetWhisper, tx.SetWhisper
+		}
+
+		err := get(gid, mid, &m)
+		if errors.Is(err, ErrNotFound) || (err == nil && !m.visibleTo(cu.ID)) {
+			return fmt.Errorf("message %q not found: %w", mid, ErrValidation)
+		}
+		if err != nil {
+			return err
+		}
+
+		mod := new(chatModeration)
+		if err := tx.GetChatModeration(gid, mod); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		if err := update(&m, mod); err != nil {
+			return err
+		}
+		m.UpdatedAt = timestamppb.Now()
+		return set(gid, mid, m)
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	withheld := m.withheld()
+	cl.broker.publish(gameEvent{Kind: MessageUpdatedEvent, GID: gid, MessageID: mid, Message: &withheld})
+	return m, nil
+}
+
+// canModify returns an error unless the user is the author of the message or an admin.
+// Authors that are muted or banned may not modify their messages.
+func (m *Message) canModify(cu *User, mod *chatModeration) error {
+	switch {
+	case cu.Admin:
+		return nil
+	case m.CreatorID != cu.ID:
+		return fmt.Errorf("only the author of a message or an admin may modify the message: %w", ErrValidation)
+	case m.Deleted:
+		return fmt.Errorf("message was deleted: %w", ErrValidation)
+	}
+	return mod.canPost(cu.ID, time.Now())
+}
+
+// editMessageHandler replaces the text of a message of the current user, or, for admins, of any message
+func (cl *GameClient[GT, G]) editMessageHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj struct {
+			messageRef
+			Text string
+		}
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		text, err := validateText(obj.Text)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		index, err := cl.getIndex(ctx, getID(ctx))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var previous []UID
+		m, err := cl.updateMessage(ctx, obj.messageRef, cu, func(m *Message, mod *chatModeration) error {
+			if err := m.canModify(cu, mod); err != nil {
+				return err
+			}
+
+			previous = m.Mentions
+			m.Text = text
+			m.Mentions = mentionsFor(&index.Header, *m)
+			m.EditedAt = timestamppb.Now()
+			return nil
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		// only players newly mentioned by the edit are notified
+		mentioned := pie.Filter(m.Mentions, func(uid UID) bool { return !slices.Contains(previous, uid) })
+		if len(mentioned) > 0 {
+			if err := cl.notify(ctx, mentionNotification(index, m, mentioned)); err != nil {
+				Warnf(ctx, "unable to notify players of mentions in message %s of %s: %v", obj.MessageID, index.id(), err)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"ID": obj.MessageID, "Message": m})
+	}
+}
+
+// deleteMessageHandler soft deletes a message of the current user, or, for admins, any message
+func (cl *GameClient[GT, G]) deleteMessageHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj messageRef
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		m, err := cl.updateMessage(ctx, obj, cu, func(m *Message, _ *chatModeration) error {
+			if m.Deleted {
+				return nil
+			}
+
+			// authors may delete their messages, even if muted
+			if !cu.Admin && m.CreatorID != cu.ID {
+				return fmt.Errorf("only the author of a message or an admin may delete the message: %w", ErrValidation)
+			}
+
+			m.Deleted = true
+			m.DeletedBy = cu.ID
+			m.DeletedAt = timestamppb.Now()
+			return nil
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"ID": obj.MessageID, "Message": m.withheld()})
+	}
+}
+
+// reactHandler toggles the reaction of the current user, with an emoji, to a message
+func (cl *GameClient[GT, G]) reactHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj struct {
+			messageRef
+			Emoji string
+		}
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := validateEmoji(obj.Emoji); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		index, err := cl.getIndex(ctx, getID(ctx))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := cl.canViewMessages(ctx, cu, index); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		m, err := cl.updateMessage(ctx, obj.messageRef, cu, func(m *Message, mod *chatModeration) error {
+			if m.Deleted {
+				return fmt.Errorf("message was deleted: %w", ErrValidation)
+			}
+
+			if err := mod.canPost(cu.ID, time.Now()); err != nil {
+				return err
+			}
+			return m.toggleReaction(obj.Emoji, cu.ID)
+		})
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"ID": obj.MessageID, "Message": m})
+	}
+}
+
+// toggleReaction adds the reaction of the user with the emoji, or removes it if the user already reacted with the emoji
+func (m *Message) toggleReaction(emoji string, uid UID) error {
+	uids := m.Reactions[emoji]
+	if i := slices.Index(uids, uid); i >= 0 {
+		uids = slices.Delete(uids, i, i+1)
+		if len(uids) == 0 {
+			delete(m.Reactions, emoji)
+			return nil
+		}
+		m.Reactions[emoji] = uids
+		return nil
+	}
+
+	if len(uids) == 0 && len(m.Reactions) >= maxReactions {
+		return fmt.Errorf("message may have at most %d distinct reactions: %w", maxReactions, ErrValidation)
+	}
+
+	if m.Reactions == nil {
+		m.Reactions = make(map[string][]UID)
+	}
+	m.Reactions[emoji] = append(uids, uid)
+	return nil
+}
+
+// validateEmoji returns an error unless the reaction is a single emoji, including any modifier or joiner sequence
+func validateEmoji(emoji string) error {
+	if emoji == "" || utf8.RuneCountInString(emoji) > maxEmojiLength {
+		return fmt.Errorf("invalid reaction %q: %w", emoji, ErrValidation)
+	}
+
+	var symbol bool
+	for _, r := range emoji {
+		switch {
+		case unicode.Is(unicode.So, r):
+			symbol = true
+		case unicode.In(r, unicode.Sk, unicode.Mn, unicode.Me), r == '\u200d':
+		default:
+			return fmt.Errorf("invalid reaction %q: %w", emoji, ErrValidation)
+		}
+	}
+
+	if !symbol {
+		return fmt.Errorf("invalid reaction %q: %w", emoji, ErrValidation)
+	}
+	return nil
+}
+
+// messageEntry provides a message and its id
+type messageEntry struct {
+	ID      string
+	Message Message
+}
+
+// messagesHandler returns a page of the message log of the game and the number of unread messages of each player.
+// Pages provide, in order of creation, the limit messages created prior to the message having the id of
+// the before query parameter, or the latest messages, if before is not provided.
+func (cl *GameClient[GT, G]) messagesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		limit, err := getIntQuery(ctx, "limit", defaultMessageLimit)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if limit < 1 || limit > maxMessageLimit {
+			JErr(ctx, fmt.Errorf("limit must be between 1 and %d: %w", maxMessageLimit, ErrValidation))
+			return
+		}
+
+		gid := getID(ctx)
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := cl.canViewMessages(ctx, cu, index); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		entries, err := cl.listMessages(ctx, gid, cu.ID)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		unread := make(map[UID]int)
+		for _, pid := range index.allPIDS() {
+			uid := index.UIDFor(pid)
+			for _, e := range entries {
+				if e.Message.unread(uid) {
+					unread[uid]++
+				}
+			}
+		}
+
+		end := len(entries)
+		if before := ctx.Query("before"); before != "" {
+			end = slices.IndexFunc(entries, func(e messageEntry) bool { return e.ID == before })
+			if end < 0 {
+				JErr(ctx, fmt.Errorf("message %q not found: %w", before, ErrValidation))
+				return
+			}
+		}
+
+		start := max(end-limit, 0)
+		page := entries[start:end]
+		if !cu.Admin {
+			for i := range page {
+				page[i].Message = page[i].Message.withheld()
+			}
+		}
+
+		var next string
+		if start > 0 {
+			next = page[0].ID
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Messages": page, "Unread": unread, "Before": next})
+	}
+}
+
+// canViewMessages returns an error unless the user may view the message log of the game.
+// Players and admins may view the message log, as may spectators of games permitting spectators, unless banned.
+func (cl *GameClient[GT, G]) canViewMessages(ctx *gin.Context, cu *User, index *index) error {
+	if cu.Admin {
+		return nil
+	}
+
+	if !index.isPlayer(cu.ID) {
+		if err := index.canSpectate(); err != nil {
+			return err
+		}
+	}
+
+	mod, err := cl.getChatModeration(ctx, index.id())
+	if err != nil {
+		return err
+	}
+	return mod.canView(cu.ID, time.Now())
+}
+
+// listMessages returns the messages of the game, and the whispers of the game readable by the user, in order of creation
+func (cl *GameClient[GT, G]) listMessages(ctx *gin.Context, gid string, uid UID) ([]messageEntry, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	docs, err := cl.store.ListMessages(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	whispers, err := cl.store.ListWhispers(ctx, gid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]messageEntry, len(docs)+len(whispers))
+	for i, doc := range append(docs, whispers...) {
+		entries[i].ID = doc.ID()
+		if err := doc.DataTo(&entries[i].Message); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(entries, func(e1, e2 messageEntry) int {
+		return e1.Message.CreatedAt.AsTime().Compare(e2.Message.CreatedAt.AsTime())
+	})
+	return entries, nil
 }
@@ -0,0 +1,42 @@
+package sn
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestReactRequiresViewingMessages(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.PUT("/react/:id", cl.reactHandler())
+	startTestGame(t, cl, 1, 2)
+
+	ctx := context.Background()
+	mid, err := cl.store.AddMessage(ctx, testGID, Message{Text: "hello", CreatorID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// users other than players may not react to messages of games not permitting spectators
+	body := `{"MessageID":"` + mid + `","Emoji":"👍"}`
+	w := serve(cl, http.MethodPut, "/react/"+testGID, 3, body, "Content-Type", "application/json")
+	if code := ErrorCode(w.Header().Get(ErrorCodeHeader)); code != ForbiddenCode {
+		t.Errorf("code = %q, want %q", code, ForbiddenCode)
+	}
+
+	var m Message
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return tx.GetMessage(testGID, mid, &m)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Reactions) != 0 {
+		t.Errorf("Reactions = %v, want none", m.Reactions)
+	}
+
+	// players may react
+	w = serve(cl, http.MethodPut, "/react/"+testGID, 2, body, "Content-Type", "application/json")
+	if code := w.Header().Get(ErrorCodeHeader); code != "" {
+		t.Errorf("player react failed with %s: %s", code, w.Body)
+	}
+}
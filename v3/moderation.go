@@ -0,0 +1,200 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/elliotchance/pie/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationAction represents an action taken by an admin against a user in the message log of a game
+type ModerationAction string
+
+const (
+	// MuteAction prevents the user from posting, editing, or reacting to messages
+	MuteAction ModerationAction = "mute"
+
+	// UnmuteAction lifts a mute of the user
+	UnmuteAction ModerationAction = "unmute"
+
+	// BanAction prevents the user from viewing the message log, in addition to muting the user
+	BanAction ModerationAction = "ban"
+
+	// UnbanAction lifts a ban of the user
+	UnbanAction ModerationAction = "unban"
+)
+
+// chatRestriction provides a mute or ban of a user.
+// Restrictions having a zero Until remain in effect until lifted.
+type chatRestriction struct {
+	UID       UID
+	Until     time.Time
+	Reason    string
+	By        UID
+	CreatedAt time.Time
+}
+
+func (r chatRestriction) activeAt(t time.Time) bool {
+	return r.Until.IsZero() || t.Before(r.Until)
+}
+
+// chatModeration provides the muted and banned users of the message log of a game
+type chatModeration struct {
+	Muted     []chatRestriction
+	Banned    []chatRestriction
+	UpdatedAt time.Time
+}
+
+// restricted returns the restriction of the user active at t, if any
+func restricted(rs []chatRestriction, uid UID, t time.Time) (chatRestriction, bool) {
+	i := slices.IndexFunc(rs, func(r chatRestriction) bool { return r.UID == uid && r.activeAt(t) })
+	if i < 0 {
+		return chatRestriction{}, false
+	}
+	return rs[i], true
+}
+
+// canPost returns an error if the user is muted or banned at t
+func (m *chatModeration) canPost(uid UID, t time.Time) error {
+	if err := m.canView(uid, t); err != nil {
+		return err
+	}
+
+	if r, ok := restricted(m.Muted, uid, t); ok {
+		return fmt.Errorf("you are muted%s: %w", r.until(), ErrForbidden)
+	}
+	return nil
+}
+
+// canView returns an error if the user is banned at t
+func (m *chatModeration) canView(uid UID, t time.Time) error {
+	if r, ok := restricted(m.Banned, uid, t); ok {
+		return fmt.Errorf("you are banned from chat%s: %w", r.until(), ErrForbidden)
+	}
+	return nil
+}
+
+func (r chatRestriction) until() string {
+	if r.Until.IsZero() {
+		return ""
+	}
+	return " until " + r.Until.UTC().Format(time.RFC1123)
+}
+
+// apply applies the action against the user.
+// Restrictions replace any prior restriction of the same kind, and expired restrictions are removed.
+func (m *chatModeration) apply(action ModerationAction, r chatRestriction) error {
+	other := func(x chatRestriction) bool { return x.UID != r.UID && x.activeAt(r.CreatedAt) }
+	switch action {
+	case MuteAction:
+		m.Muted = append(pie.Filter(m.Muted, other), r)
+	case UnmuteAction:
+		m.Muted = pie.Filter(m.Muted, other)
+	case BanAction:
+		m.Banned = append(pie.Filter(m.Banned, other), r)
+	case UnbanAction:
+		m.Banned = pie.Filter(m.Banned, other)
+	default:
+		return fmt.Errorf("unknown moderation action %q: %w", action, ErrValidation)
+	}
+	m.UpdatedAt = r.CreatedAt
+	return nil
+}
+
+func (cl *GameClient[GT, G]) getChatModeration(ctx context.Context, gid string) (*chatModeration, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	m := new(chatModeration)
+	if err := cl.store.GetChatModeration(ctx, gid, m); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return m, nil
+}
+
+// moderationHandler returns the muted and banned users of the message log of a game to an admin
+func (cl *GameClient[GT, G]) moderationHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if _, err := cl.RequireAdmin(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		m, err := cl.getChatModeration(ctx, getID(ctx))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Moderation": m})
+	}
+}
+
+// moderateHandler mutes, unmutes, bans, or unbans a user of the message log of a game.
+// Only admins may moderate.
+func (cl *GameClient[GT, G]) moderateHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireAdmin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var obj struct {
+			Action ModerationAction
+			UID    UID
+			Until  time.Time
+			Reason string
+		}
+		if err := ctx.ShouldBind(&obj); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		now := time.Now()
+		verr := NewVError()
+		if obj.UID == 0 {
+			verr.AddFieldf("UID", "user must be provided")
+		}
+
+		if !obj.Until.IsZero() && !obj.Until.After(now) {
+			verr.AddFieldf("Until", "until must be in the future")
+		}
+
+		if err := verr.Err(); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		r := chatRestriction{UID: obj.UID, Until: obj.Until, Reason: obj.Reason, By: cu.ID, CreatedAt: now}
+		gid := getID(ctx)
+		m := new(chatModeration)
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			*m = chatModeration{}
+			if err := tx.GetChatModeration(gid, m); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+
+			if err := m.apply(obj.Action, r); err != nil {
+				return err
+			}
+			return tx.SetChatModeration(gid, m)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Moderation": m, "Message": fmt.Sprintf("Applied %s to user %d.", obj.Action, obj.UID)})
+	}
+}
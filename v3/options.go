@@ -16,6 +16,12 @@ type options struct {
 	secretsDSURL     string
 	prefix           string
 	home             string
+	store            Store
+	rater            Rater
+	mailer           Mailer
+	templates        *templateRegistry
+	conflictPolicy   ConflictPolicy
+	errorFormat      ErrorFormat
 }
 
 // WithProjectID sets the Google Cloud Project.
@@ -0,0 +1,431 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationKind represents the channel via which a notification is delivered
+type NotificationKind string
+
+const (
+	// PushNotification indicates a web push notification to the subscriptions of users of a game
+	PushNotification NotificationKind = "push"
+
+	// TopicNotification indicates a web push notification to a firebase messaging topic
+	TopicNotification NotificationKind = "topic"
+
+	// EmailNotification indicates email messages
+	EmailNotification NotificationKind = "email"
+
+	// WebhookNotification indicates a POST of the event of the notification to a webhook of a user
+	WebhookNotification NotificationKind = "webhook"
+
+	// EventNotification indicates an event routed to the channels of each recipient per the preferences of the recipient
+	EventNotification NotificationKind = "event"
+)
+
+const (
+	// maxOutboxAttempts provides the number of failed delivery attempts after which a notification is dead-lettered
+	maxOutboxAttempts = 10
+
+	// outboxBackoff provides the delay following the first failed delivery attempt.
+	// The delay doubles following each subsequent failed attempt, up to maxOutboxBackoff.
+	outboxBackoff    = 30 * time.Second
+	maxOutboxBackoff = 6 * time.Hour
+
+	// outboxLease provides the period for which a notification is claimed by a delivery attempt.
+	// Should the attempt not complete (e.g., the instance shuts down), the notification is retried after the lease expires.
+	outboxLease = 5 * time.Minute
+
+	// outboxBatchSize provides the maximum number of notifications delivered per pass of the outbox
+	outboxBatchSize = 100
+
+	// outboxPollInterval provides the interval at which the outbox worker checks for notifications due for retry
+	outboxPollInterval = time.Minute
+)
+
+// errNoMessaging indicates push and topic notifications cannot be sent, as firebase messaging is not configured.
+// Such notifications are retried, and eventually dead-lettered, rather than dropped.
+var errNoMessaging = errors.New("firebase messaging is not configured")
+
+// notification provides a notification stored in the outbox until delivered.
+// Notifications are written to the outbox in the same transaction as the change prompting them,
+// and thereafter delivered by the outbox worker with retries.
+type notification struct {
+	Kind NotificationKind
+	GID  string
+	// UserIDS provides the users whose subscriptions to the game receive a push notification
+	UserIDS []UID
+	// Tokens, if not empty, limits delivery of a push notification to the tokens failing a prior attempt
+	Tokens   []string
+	Topic    string
+	Title    string
+	Body     string
+	ImageURL string
+	Emails   []Email
+
+	// Event, Recipients, GameType, and Data provide the event of an event notification.
+	// Content is rendered from the templates for the event, per the language of each recipient, upon routing.
+	Event      NotificationEvent
+	Recipients []recipient
+	GameType   Type
+	Data       H
+	WebhookURL string
+
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	// DeadLettered indicates delivery was abandoned after maxOutboxAttempts failed attempts.
+	// Dead-lettered notifications remain in the outbox for inspection.
+	DeadLettered bool
+	CreatedAt    time.Time
+}
+
+// pushNotification returns a web push notification to the subscriptions of the users to the game
+func pushNotification(gid string, uids []UID, title, body string) notification {
+	return notification{
+		Kind:     PushNotification,
+		GID:      gid,
+		UserIDS:  uids,
+		Title:    title,
+		Body:     body,
+		ImageURL: notificationImageURL,
+	}
+}
+
+// topicNotification returns a web push notification to the firebase messaging topic
+func topicNotification(topic, title, body string) notification {
+	return notification{
+		Kind:     TopicNotification,
+		Topic:    topic,
+		Title:    title,
+		Body:     body,
+		ImageURL: notificationImageURL,
+	}
+}
+
+// emailNotification returns a notification sending the email messages
+func emailNotification(gid string, emails ...Email) notification {
+	return notification{Kind: EmailNotification, GID: gid, Emails: emails}
+}
+
+// empty returns true if the notification has no recipients
+func (n notification) empty() bool {
+	switch n.Kind {
+	case PushNotification:
+		return len(n.UserIDS) == 0
+	case TopicNotification:
+		return n.Topic == ""
+	case WebhookNotification:
+		return n.WebhookURL == ""
+	case EventNotification:
+		return len(n.Recipients) == 0
+	default:
+		return len(n.Emails) == 0
+	}
+}
+
+func (n notification) messagingNotification() *messaging.Notification {
+	return &messaging.Notification{Title: n.Title, Body: n.Body, ImageURL: n.ImageURL}
+}
+
+// txNotify adds the notifications to the outbox.
+// Notifications are due immediately, unless NextAttemptAt is set.
+// Delivery is prompted by kickOutbox once the transaction succeeds.
+func (cl *GameClient[GT, G]) txNotify(tx Tx, ns ...notification) error {
+	now := time.Now()
+	for _, n := range ns {
+		if n.empty() {
+			continue
+		}
+
+		if n.NextAttemptAt.IsZero() {
+			n.NextAttemptAt = now
+		}
+		n.CreatedAt = now
+		if err := tx.SetOutboxEntry(newDocID(), n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify adds the notifications to the outbox and prompts their delivery
+func (cl *GameClient[GT, G]) notify(ctx context.Context, ns ...notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return cl.txNotify(tx, ns...)
+	}); err != nil {
+		return err
+	}
+
+	cl.kickOutbox()
+	return nil
+}
+
+// kickOutbox prompts the outbox worker to deliver pending notifications without blocking
+func (cl *Client) kickOutbox() {
+	select {
+	case cl.outbox <- struct{}{}:
+	default:
+	}
+}
+
+// runOutbox delivers pending notifications whenever prompted by kickOutbox, and periodically
+// to retry failed deliveries, until ctx is done.
+func (cl *GameClient[GT, G]) runOutbox(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cl.outbox:
+		case <-ticker.C:
+		}
+
+		if _, err := cl.deliverOutbox(ctx); err != nil {
+			Warnf(ctx, "unable to deliver outbox: %v", err)
+		}
+	}
+}
+
+// outboxHandler delivers pending notifications.
+// Intended to be periodically invoked by App Engine cron, so notifications pending when an instance
+// shuts down are delivered.
+func (cl *GameClient[GT, G]) outboxHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if err := cl.requireCron(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		delivered, err := cl.deliverOutbox(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Delivered": delivered})
+	}
+}
+
+// deliverOutbox attempts delivery of the notifications due for delivery and returns the number delivered.
+// Digests due for sending are first added to the outbox.
+func (cl *GameClient[GT, G]) deliverOutbox(ctx context.Context) (int, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if err := cl.sendDigests(ctx); err != nil {
+		Warnf(ctx, "unable to send digests: %v", err)
+	}
+
+	now := time.Now()
+	docs, err := cl.store.ListOutbox(ctx, now, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, doc := range docs {
+		ok, err := cl.deliverNotification(ctx, doc.ID(), now)
+		if err != nil {
+			Warnf(ctx, "unable to deliver notification %s: %v", doc.ID(), err)
+			continue
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// deliverNotification claims and attempts delivery of the notification having the id.
+// Returns true if the notification was delivered, and false if the notification was claimed by another attempt.
+// Delivered notifications are removed from the outbox. Otherwise, the next attempt is scheduled per an
+// exponential backoff, or the notification is dead-lettered if maxOutboxAttempts attempts have failed.
+func (cl *GameClient[GT, G]) deliverNotification(ctx context.Context, id string, now time.Time) (bool, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	var (
+		n       notification
+		claimed bool
+	)
+
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		claimed = false
+		n = notification{}
+		if err := tx.GetOutboxEntry(id, &n); errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if n.DeadLettered || n.NextAttemptAt.After(now) {
+			return nil
+		}
+
+		n.Attempts++
+		n.NextAttemptAt = now.Add(outboxLease)
+		claimed = true
+		return tx.SetOutboxEntry(id, n)
+	}); err != nil || !claimed {
+		return false, err
+	}
+
+	if n.Kind == EventNotification {
+		// routing replaces the event notification with the notifications of its recipients
+		if err := cl.route(ctx, id, n); err != nil {
+			return cl.retry(ctx, id, n, err)
+		}
+		return true, nil
+	}
+
+	sendErr := cl.send(ctx, &n)
+	if sendErr == nil {
+		return true, cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			return tx.DeleteOutboxEntry(id)
+		})
+	}
+	return cl.retry(ctx, id, n, sendErr)
+}
+
+// retry schedules the next delivery attempt of the notification following a failed attempt,
+// or dead-letters the notification if maxOutboxAttempts attempts have failed.
+func (cl *GameClient[GT, G]) retry(ctx context.Context, id string, n notification, sendErr error) (bool, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	n.LastError = sendErr.Error()
+	if n.Attempts >= maxOutboxAttempts {
+		n.DeadLettered = true
+	} else {
+		n.NextAttemptAt = time.Now().Add(min(outboxBackoff<<(n.Attempts-1), maxOutboxBackoff))
+	}
+
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return tx.SetOutboxEntry(id, n)
+	}); err != nil {
+		return false, errors.Join(sendErr, err)
+	}
+	return false, fmt.Errorf("attempt %d failed: %w", n.Attempts, sendErr)
+}
+
+// send sends the notification via the channel of its kind
+func (cl *GameClient[GT, G]) send(ctx context.Context, n *notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	switch n.Kind {
+	case PushNotification:
+		return cl.sendPush(ctx, n)
+	case TopicNotification:
+		if cl.FCM == nil {
+			return errNoMessaging
+		}
+		_, err := cl.FCM.Send(ctx, &messaging.Message{
+			Topic:        n.Topic,
+			Notification: n.messagingNotification(),
+			Webpush:      webpushConfig(),
+		})
+		return err
+	case EmailNotification:
+		return cl.mailer.Send(ctx, n.Emails...)
+	case WebhookNotification:
+		return sendWebhook(ctx, n)
+	default:
+		return fmt.Errorf("unknown notification kind %q", n.Kind)
+	}
+}
+
+// sendPush sends a push notification to the subscriptions of the users of the notification.
+// Tokens rejected by firebase messaging as invalid or unregistered are removed from the subscriptions.
+// Tokens failing for other reasons are retained by the notification, such that a retry is sent only to those tokens.
+func (cl *GameClient[GT, G]) sendPush(ctx context.Context, n *notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if cl.FCM == nil {
+		return errNoMessaging
+	}
+
+	var tokens []string
+	owners := make(map[string]UID)
+	for _, uid := range n.UserIDS {
+		subs, err := cl.getSubscriptions(ctx, n.GID, uid)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, token := range subs.toTokenStrings() {
+			if len(n.Tokens) == 0 || slices.Contains(n.Tokens, token) {
+				tokens = append(tokens, token)
+				owners[token] = uid
+			}
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	response, err := cl.FCM.SendEachForMulticast(ctx, &messaging.MulticastMessage{
+		Tokens:       tokens,
+		Notification: n.messagingNotification(),
+		Webpush:      webpushConfig(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		failed  []string
+		sendErr error
+	)
+	invalid := make(map[UID][]string)
+	for i, r := range response.Responses {
+		switch {
+		case r.Success:
+		case messaging.IsUnregistered(r.Error) || messaging.IsInvalidArgument(r.Error) || messaging.IsSenderIDMismatch(r.Error):
+			invalid[owners[tokens[i]]] = append(invalid[owners[tokens[i]]], tokens[i])
+		default:
+			failed = append(failed, tokens[i])
+			sendErr = errors.Join(sendErr, r.Error)
+		}
+	}
+
+	for uid, tokens := range invalid {
+		if err := cl.pruneTokens(ctx, n.GID, uid, tokens); err != nil {
+			Warnf(ctx, "unable to prune tokens of %d for %s: %v", uid, n.GID, err)
+		}
+	}
+
+	n.Tokens = failed
+	return sendErr
+}
+
+func webpushConfig() *messaging.WebpushConfig {
+	return &messaging.WebpushConfig{
+		FCMOptions: &messaging.WebpushFCMOptions{
+			Link: "https://www.slothninja.com",
+		},
+	}
+}
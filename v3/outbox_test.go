@@ -0,0 +1,192 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testMailer fails the first fail sends
+type testMailer struct {
+	fail  int
+	sends int
+}
+
+func (m *testMailer) Send(_ context.Context, _ ...Email) error {
+	m.sends++
+	if m.sends <= m.fail {
+		return fmt.Errorf("send %d failed", m.sends)
+	}
+	return nil
+}
+
+// addTestEmail adds an email notification to the outbox and returns its id
+func addTestEmail(t *testing.T, cl *testClient) string {
+	t.Helper()
+
+	ctx := context.Background()
+	n := notification{Kind: EmailNotification, Emails: []Email{{ToEmail: "user@example.com", Subject: "test"}}}
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return cl.txNotify(tx, n)
+	}); err != nil {
+		t.Fatalf("txNotify: %v", err)
+	}
+
+	docs, err := cl.store.ListOutbox(ctx, time.Now(), outboxBatchSize)
+	if err != nil {
+		t.Fatalf("ListOutbox: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("len(ListOutbox) = %d, want 1", len(docs))
+	}
+	return docs[0].ID()
+}
+
+func getTestNotification(t *testing.T, cl *testClient, id string) (notification, error) {
+	t.Helper()
+
+	var n notification
+	err := cl.store.RunTransaction(context.Background(), func(_ context.Context, tx Tx) error {
+		return tx.GetOutboxEntry(id, &n)
+	})
+	return n, err
+}
+
+func TestDeliverNotificationRemovesDelivered(t *testing.T) {
+	m := new(testMailer)
+	cl := newTestClient(t, WithMailer(m))
+	id := addTestEmail(t, cl)
+
+	ok, err := cl.deliverNotification(context.Background(), id, time.Now())
+	if !ok || err != nil {
+		t.Fatalf("deliverNotification = %v, %v, want true, nil", ok, err)
+	}
+
+	if _, err := getTestNotification(t, cl, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOutboxEntry = %v, want ErrNotFound", err)
+	}
+
+	if m.sends != 1 {
+		t.Errorf("sends = %d, want 1", m.sends)
+	}
+}
+
+func TestDeliverNotificationRetriesFailures(t *testing.T) {
+	m := &testMailer{fail: 2}
+	cl := newTestClient(t, WithMailer(m))
+	id := addTestEmail(t, cl)
+
+	ctx := context.Background()
+	now := time.Now()
+	for attempt := 1; attempt <= m.fail; attempt++ {
+		ok, err := cl.deliverNotification(ctx, id, now)
+		if ok || err == nil {
+			t.Fatalf("attempt %d: deliverNotification = %v, %v, want false and an error", attempt, ok, err)
+		}
+
+		n, err := getTestNotification(t, cl, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n.Attempts != attempt || n.DeadLettered || n.LastError == "" {
+			t.Errorf("attempt %d: Attempts = %d, DeadLettered = %v, LastError = %q", attempt, n.Attempts, n.DeadLettered, n.LastError)
+		}
+
+		// failed notifications are not delivered again before the backoff elapses
+		if !n.NextAttemptAt.After(now) {
+			t.Errorf("attempt %d: NextAttemptAt = %v, want after %v", attempt, n.NextAttemptAt, now)
+		}
+
+		if ok, err := cl.deliverNotification(ctx, id, now); ok || err != nil {
+			t.Errorf("attempt %d: early deliverNotification = %v, %v, want false, nil", attempt, ok, err)
+		}
+		now = n.NextAttemptAt
+	}
+
+	if ok, err := cl.deliverNotification(ctx, id, now); !ok || err != nil {
+		t.Fatalf("deliverNotification = %v, %v, want true, nil", ok, err)
+	}
+
+	if m.sends != m.fail+1 {
+		t.Errorf("sends = %d, want %d", m.sends, m.fail+1)
+	}
+}
+
+func TestDeliverNotificationDeadLetters(t *testing.T) {
+	m := &testMailer{fail: maxOutboxAttempts + 1}
+	cl := newTestClient(t, WithMailer(m))
+	id := addTestEmail(t, cl)
+
+	ctx := context.Background()
+	now := time.Now()
+	for range maxOutboxAttempts {
+		if ok, err := cl.deliverNotification(ctx, id, now); ok || err == nil {
+			t.Fatalf("deliverNotification = %v, %v, want false and an error", ok, err)
+		}
+
+		n, err := getTestNotification(t, cl, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = n.NextAttemptAt
+	}
+
+	n, err := getTestNotification(t, cl, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !n.DeadLettered || n.Attempts != maxOutboxAttempts {
+		t.Errorf("DeadLettered = %v, Attempts = %d, want true, %d", n.DeadLettered, n.Attempts, maxOutboxAttempts)
+	}
+
+	// dead-lettered notifications remain in the outbox, but are neither listed nor delivered
+	later := now.Add(maxOutboxBackoff)
+	docs, err := cl.store.ListOutbox(ctx, later, outboxBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 0 {
+		t.Errorf("len(ListOutbox) = %d, want 0", len(docs))
+	}
+
+	if ok, err := cl.deliverNotification(ctx, id, later); ok || err != nil {
+		t.Errorf("deliverNotification = %v, %v, want false, nil", ok, err)
+	}
+
+	if m.sends != maxOutboxAttempts {
+		t.Errorf("sends = %d, want %d", m.sends, maxOutboxAttempts)
+	}
+}
+
+func TestDeliverNotificationKeepsPushWithoutMessaging(t *testing.T) {
+	cl := newTestClient(t)
+
+	ctx := context.Background()
+	if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		return cl.txNotify(tx, topicNotification("1", "title", "body"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := cl.store.ListOutbox(ctx, time.Now(), outboxBatchSize)
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("ListOutbox = %d docs, %v, want 1 doc", len(docs), err)
+	}
+	id := docs[0].ID()
+
+	if ok, err := cl.deliverNotification(ctx, id, time.Now()); ok || !errors.Is(err, errNoMessaging) {
+		t.Errorf("deliverNotification = %v, %v, want false, %v", ok, err, errNoMessaging)
+	}
+
+	// the notification is retained for retry, rather than dropped as delivered
+	n, err := getTestNotification(t, cl, id)
+	if err != nil {
+		t.Fatalf("GetOutboxEntry: %v", err)
+	}
+	if n.Attempts != 1 || n.DeadLettered {
+		t.Errorf("Attempts = %d, DeadLettered = %v, want 1, false", n.Attempts, n.DeadLettered)
+	}
+}
@@ -0,0 +1,121 @@
+package sn
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PatchOp provides a single operation of a JSON patch (RFC 6902)
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON omits the value of remove operations, as RFC 6902 defines no value for them,
+// while retaining null values of add and replace operations.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+
+	type patchOp PatchOp
+	return json.Marshal(patchOp(op))
+}
+
+// Diff returns a JSON patch (RFC 6902) that transforms the JSON encoding of from into the JSON encoding of to.
+// from and to must be suitable for marshalling via json.Marshal
+func Diff(from, to any) ([]PatchOp, error) {
+	v1, err := toJSONValue(from)
+	if err != nil {
+		return nil, err
+	}
+
+	v2, err := toJSONValue(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffValues(nil, "", v1, v2), nil
+}
+
+func toJSONValue(obj any) (any, error) {
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	err = json.Unmarshal(bs, &v)
+	return v, err
+}
+
+func diffValues(ops []PatchOp, path string, v1, v2 any) []PatchOp {
+	switch m1 := v1.(type) {
+	case map[string]any:
+		if m2, ok := v2.(map[string]any); ok {
+			return diffMaps(ops, path, m1, m2)
+		}
+	case []any:
+		if a2, ok := v2.([]any); ok {
+			return diffArrays(ops, path, m1, a2)
+		}
+	}
+
+	if reflect.DeepEqual(v1, v2) {
+		return ops
+	}
+	return append(ops, PatchOp{Op: "replace", Path: path, Value: v2})
+}
+
+func diffMaps(ops []PatchOp, path string, m1, m2 map[string]any) []PatchOp {
+	for _, k := range sortedKeys(m1) {
+		p := path + "/" + escapePointer(k)
+		v2, found := m2[k]
+		if !found {
+			ops = append(ops, PatchOp{Op: "remove", Path: p})
+			continue
+		}
+		ops = diffValues(ops, p, m1[k], v2)
+	}
+
+	for _, k := range sortedKeys(m2) {
+		if _, found := m1[k]; !found {
+			ops = append(ops, PatchOp{Op: "add", Path: path + "/" + escapePointer(k), Value: m2[k]})
+		}
+	}
+	return ops
+}
+
+func diffArrays(ops []PatchOp, path string, a1, a2 []any) []PatchOp {
+	common := min(len(a1), len(a2))
+	for i := range common {
+		ops = diffValues(ops, path+"/"+strconv.Itoa(i), a1[i], a2[i])
+	}
+
+	// remove from the end, so indices of remaining elements are unaffected
+	for i := len(a1) - 1; i >= common; i-- {
+		ops = append(ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+
+	for i := common; i < len(a2); i++ {
+		ops = append(ops, PatchOp{Op: "add", Path: path + "/-", Value: a2[i]})
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(k string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+}
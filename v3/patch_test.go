@@ -0,0 +1,25 @@
+package sn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPatchOpMarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		op   PatchOp
+		want string
+	}{
+		{op: PatchOp{Op: "remove", Path: "/a"}, want: `{"op":"remove","path":"/a"}`},
+		{op: PatchOp{Op: "replace", Path: "/a"}, want: `{"op":"replace","path":"/a","value":null}`},
+		{op: PatchOp{Op: "add", Path: "/a", Value: 1}, want: `{"op":"add","path":"/a","value":1}`},
+	} {
+		bs, err := json.Marshal(tc.op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bs) != tc.want {
+			t.Errorf("json.Marshal(%+v) = %s, want %s", tc.op, bs, tc.want)
+		}
+	}
+}
@@ -149,7 +149,15 @@ func (ps Players[T, P]) IndexFor(p1 P) (int, bool) {
 	return index, found
 }
 
-// Randomize randomizes the order of the players in the Players slice
+// Randomize randomizes the order of the players in the Players slice.
+//
+// Deprecated: Randomize uses the global random source, and thus orders the players irreproducibly.
+// Use RandomizeWith(g.Rand()), so the order is derived from the seed of the game.
 func (ps Players[T, P]) Randomize() {
 	rand.Shuffle(len(ps), func(i, j int) { ps[i], ps[j] = ps[j], ps[i] })
 }
+
+// RandomizeWith randomizes the order of the players in the Players slice using the random source r
+func (ps Players[T, P]) RandomizeWith(r *rand.Rand) {
+	r.Shuffle(len(ps), func(i, j int) { ps[i], ps[j] = ps[j], ps[i] })
+}
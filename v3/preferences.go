@@ -0,0 +1,290 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationEvent represents an event of which users may be notified
+type NotificationEvent string
+
+const (
+	// YourTurnEvent indicates it is the turn of the user
+	YourTurnEvent NotificationEvent = "your-turn"
+
+	// GameStartedEvent indicates a game of the user started
+	GameStartedEvent NotificationEvent = "game-started"
+
+	// GameEndedEvent indicates a game of the user ended
+	GameEndedEvent NotificationEvent = "game-ended"
+
+	// ChatMessageEvent indicates a message was added to the message log of a game of the user
+	ChatMessageEvent NotificationEvent = "chat-message"
+
+	// MentionEvent indicates the user was mentioned, via @name, in a message of the message log of a game of the user
+	MentionEvent NotificationEvent = "mention"
+
+	// InvitationFilledEvent indicates an invitation created by the user filled, thereby starting the game
+	InvitationFilledEvent NotificationEvent = "invitation-filled"
+
+	// TurnReminderEvent indicates the turn deadline of the user approaches
+	TurnReminderEvent NotificationEvent = "turn-reminder"
+
+	// GameAbandonedEvent indicates a game of the user was abandoned after a player exceeded the turn limit
+	GameAbandonedEvent NotificationEvent = "game-abandoned"
+
+	// InvitationClosedEvent indicates an invitation joined by the user expired or was cancelled,
+	// or the user was removed from the invitation by its creator
+	InvitationClosedEvent NotificationEvent = "invitation-closed"
+
+	// InvitedEvent indicates the user was invited directly to an invitation
+	InvitedEvent NotificationEvent = "invited"
+
+	// InvitationDeclinedEvent indicates a user invited directly to an invitation created by the user declined
+	InvitationDeclinedEvent NotificationEvent = "invitation-declined"
+)
+
+var notificationEvents = []NotificationEvent{
+	YourTurnEvent,
+	GameStartedEvent,
+	GameEndedEvent,
+	ChatMessageEvent,
+	MentionEvent,
+	InvitationFilledEvent,
+	TurnReminderEvent,
+	GameAbandonedEvent,
+	InvitationClosedEvent,
+	InvitedEvent,
+	InvitationDeclinedEvent,
+}
+
+// Channel represents a channel via which users are notified of events
+type Channel string
+
+const (
+	// EmailChannel notifies users via email
+	EmailChannel Channel = "email"
+
+	// PushChannel notifies users via web push notifications
+	PushChannel Channel = "push"
+
+	// WebhookChannel notifies users via a POST to the webhook of the user
+	WebhookChannel Channel = "webhook"
+)
+
+var channels = []Channel{EmailChannel, PushChannel, WebhookChannel}
+
+// preferences provides the notification preferences of a user
+type preferences struct {
+	// Channels provides the channels via which the user is notified of each event.
+	// Events absent from Channels are notified per the default preferences.
+	Channels map[NotificationEvent][]Channel
+	// WebhookURL provides the https url to which events are posted for the WebhookChannel
+	WebhookURL string
+	// QuietStart and QuietEnd provide the hours (0-23), in TimeZone, between which notifications are held.
+	// Held notifications are delivered at the end of quiet hours.
+	// Quiet hours are disabled if QuietStart equals QuietEnd.
+	QuietStart int
+	QuietEnd   int
+	// TimeZone provides the IANA time zone of the user. Defaults to UTC.
+	TimeZone string
+	// Digest indicates email notifications are collected and sent once daily at DigestHour, in TimeZone
+	Digest     bool
+	DigestHour int
+	// Language provides the BCP 47 language tag (e.g., de or pt-BR) in which the user is notified.
+	// Defaults to English, as do languages for which no templates exist.
+	Language  string
+	UpdatedAt time.Time
+}
+
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// defaultPreferences returns the preferences of users that have not set preferences.
+// Users are notified of all events via push notifications. Users are notified of turn reminders via email,
+// if the EmailReminders setting of the user is true, and of all other events, but chat messages, via email,
+// if the EmailNotifications setting of the user is true.
+func defaultPreferences(r recipient) *preferences {
+	p := &preferences{Channels: make(map[NotificationEvent][]Channel)}
+	for _, e := range notificationEvents {
+		p.Channels[e] = []Channel{PushChannel}
+		email := r.EmailNotifications && e != ChatMessageEvent
+		if e == TurnReminderEvent {
+			email = r.EmailReminders
+		}
+		if email {
+			p.Channels[e] = append(p.Channels[e], EmailChannel)
+		}
+	}
+	return p
+}
+
+func (p *preferences) location() *time.Location {
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// quietUntil returns the end of the quiet hours including t.
+// Returns the zero time if t is not within quiet hours.
+func (p *preferences) quietUntil(t time.Time) time.Time {
+	if p.QuietStart == p.QuietEnd {
+		return time.Time{}
+	}
+
+	local := t.In(p.location())
+	h := local.Hour()
+	quiet := p.QuietStart <= h && h < p.QuietEnd
+	if p.QuietStart > p.QuietEnd {
+		// quiet hours span midnight
+		quiet = h >= p.QuietStart || h < p.QuietEnd
+	}
+
+	if !quiet {
+		return time.Time{}
+	}
+	return nextHour(local, p.QuietEnd)
+}
+
+// nextDigest returns the time at which a digest started at t is sent
+func (p *preferences) nextDigest(t time.Time) time.Time {
+	return nextHour(t.In(p.location()), p.DigestHour)
+}
+
+// nextHour returns the first time after t having the hour, in the location of t, on the hour
+func nextHour(t time.Time, hour int) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (p *preferences) validate(ctx context.Context) error {
+	for e, chs := range p.Channels {
+		if !slices.Contains(notificationEvents, e) {
+			return fmt.Errorf("unknown event %q: %w", e, ErrValidation)
+		}
+
+		for _, ch := range chs {
+			if !slices.Contains(channels, ch) {
+				return fmt.Errorf("unknown channel %q: %w", ch, ErrValidation)
+			}
+
+			if ch == WebhookChannel && p.WebhookURL == "" {
+				return fmt.Errorf("webhook url must be provided for webhook channel: %w", ErrValidation)
+			}
+		}
+	}
+
+	if p.WebhookURL != "" {
+		if err := validateWebhookURL(ctx, p.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range []int{p.QuietStart, p.QuietEnd, p.DigestHour} {
+		if h < 0 || h > 23 {
+			return fmt.Errorf("hours must be between 0 and 23: %w", ErrValidation)
+		}
+	}
+
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q: %w", p.TimeZone, ErrValidation)
+	}
+
+	if p.Language != "" && !languagePattern.MatchString(p.Language) {
+		return fmt.Errorf("invalid language %q: %w", p.Language, ErrValidation)
+	}
+	return nil
+}
+
+// getPreferences returns the preferences of the recipient.
+// Returns the default preferences per the email settings of the recipient, if the recipient has not set preferences.
+func (cl *GameClient[GT, G]) getPreferences(ctx context.Context, r recipient) (*preferences, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	p := new(preferences)
+	err := cl.store.GetPreferences(ctx, r.UID, p)
+	if errors.Is(err, ErrNotFound) {
+		return defaultPreferences(r), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// events introduced after the user set preferences default per the email settings of the recipient
+	for e, chs := range defaultPreferences(r).Channels {
+		if _, ok := p.Channels[e]; !ok {
+			if p.Channels == nil {
+				p.Channels = make(map[NotificationEvent][]Channel)
+			}
+			p.Channels[e] = chs
+		}
+	}
+	return p, nil
+}
+
+// preferencesHandler returns the notification preferences of the current user
+func (cl *GameClient[GT, G]) preferencesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		p, err := cl.getPreferences(ctx, recipientFor(cu))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Preferences": p})
+	}
+}
+
+// updatePreferencesHandler replaces the notification preferences of the current user
+func (cl *GameClient[GT, G]) updatePreferencesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		p := new(preferences)
+		if err := ctx.ShouldBind(p); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := p.validate(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		p.UpdatedAt = time.Now()
+		if err := cl.store.SetPreferences(ctx, cu.ID, p); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Preferences": p, "Message": "Notification preferences updated."})
+	}
+}
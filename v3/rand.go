@@ -0,0 +1,43 @@
+package sn
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+)
+
+// NewSeed returns a new random, non-zero seed suitable for Header.Seed
+func NewSeed() int64 {
+	for {
+		if seed := rand.Int64(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// Rand returns a source of randomness for the game.
+// The stream of the source is derived from the seed of the game and the current revision of the undo stack.
+// Thus, the random outcomes of any revision can be regenerated exactly, and
+// undoing and redoing an action can not be used to obtain different random outcomes.
+// Successive calls during the same revision return the same source, thus continuing the stream.
+func (g *Game[S, T, P]) Rand() *rand.Rand {
+	rev := g.stack().Current
+	if g.rng == nil || g.rngRev != rev {
+		g.rng, g.rngRev = newRand(g.header(), rev), rev
+	}
+	return g.rng
+}
+
+func newRand(h *Header, rev Rev) *rand.Rand {
+	return rand.New(rand.NewPCG(seedFor(h), uint64(rev)))
+}
+
+// seedFor returns the seed of the header.
+// Games created prior to the introduction of seeds fall back to a seed derived from the game id.
+func seedFor(h *Header) uint64 {
+	if h.Seed != 0 {
+		return uint64(h.Seed)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(h.ID))
+	return hash.Sum64()
+}
@@ -0,0 +1,176 @@
+package sn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/elliotchance/pie/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// Rating provides the rating of a user for a rating system, game type, and player count
+type Rating struct {
+	UID        UID
+	Name       string
+	EmailHash  string
+	GravType   string
+	System     string
+	Type       Type
+	NumPlayers int
+	// Rating provides the displayed rating
+	Rating int
+	// Mu provides the estimated skill of the user, as used by the rating system
+	Mu float64
+	// Deviation provides the uncertainty of Mu (e.g., Glicko rating deviation or TrueSkill sigma)
+	Deviation float64
+	// Volatility provides the expected fluctuation of Mu (e.g., Glicko-2 volatility)
+	Volatility float64
+	// Played provides the number of rated games played
+	Played int64
+	// Won provides the number of rated games won
+	Won int64
+	// Finish provides the sum of finishing positions of rated games
+	Finish int64
+	// WinPercentage provides the win percentage of rated games
+	WinPercentage float32
+	// FinishAvg provides the average finishing position of rated games
+	FinishAvg float32
+	UpdatedAt time.Time
+}
+
+// Rater provides a rating system used to update the ratings of players at the end of a game
+type Rater interface {
+	// System returns a name unique to the rating system (e.g., "elo")
+	System() string
+
+	// Initial returns the rating of a user yet to be rated
+	Initial() Rating
+
+	// Rate returns updated ratings for the players of a game, given their current ratings and
+	// places (e.g., 1 for first, 2 for second, with tied players sharing a place).
+	// Returned ratings must be in the same order as the provided ratings.
+	Rate(ratings []Rating, places []int) []Rating
+}
+
+// WithRater sets the rating system used to rate games.
+// If not set, ratings are updated using EloRater.
+func WithRater(r Rater) Option {
+	return func(cl *Client) *Client {
+		cl.rater = r
+		return cl
+	}
+}
+
+type placesMap map[UID]int
+
+// Firestore only supports string values for map keys
+type placesSMap map[string]int
+
+// ratingID returns the id of the rating document for the user, rating system, game type and player count
+func ratingID(uid UID, system string, t Type, numPlayers int) string {
+	return fmt.Sprintf("%d-%s-%s-%d", uid, system, t, numPlayers)
+}
+
+func (cl *Client) newRating(u *User, t Type, numPlayers int) Rating {
+	r := cl.rater.Initial()
+	r.UID = u.ID
+	r.Name = u.Name
+	r.EmailHash = u.EmailHash
+	r.GravType = u.GravType
+	r.System = cl.rater.System()
+	r.Type = t
+	r.NumPlayers = numPlayers
+	r.UpdatedAt = time.Now()
+	return r
+}
+
+// getRatings returns ratings of users for the game type and player count.
+// Users yet to be rated are provided the initial rating of the rating system of the client,
+// or, if rated by Elo, their legacy Elo rating, if any.
+func (cl *GameClient[GT, G]) getRatings(ctx *gin.Context, t Type, numPlayers int, us ...*User) ([]Rating, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	ratings := make([]Rating, len(us))
+	for i, u := range us {
+		var r Rating
+		switch err := cl.store.GetRating(ctx, ratingID(u.ID, cl.rater.System(), t, numPlayers), &r); {
+		case errors.Is(err, ErrNotFound):
+			if ratings[i], err = cl.initialRating(ctx, u, t, numPlayers); err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		default:
+			ratings[i] = r
+		}
+	}
+	return ratings, nil
+}
+
+// initialRating returns the rating of a user yet to be rated for the game type and player count.
+// Elo ratings were formerly kept per user, i.e., Elo/{uid}, thus such legacy ratings seed the Elo ratings of the user.
+func (cl *GameClient[GT, G]) initialRating(ctx *gin.Context, u *User, t Type, numPlayers int) (Rating, error) {
+	r := cl.newRating(u, t, numPlayers)
+	if r.System != (EloRater{}).System() {
+		return r, nil
+	}
+
+	var legacy Rating
+	switch err := cl.store.GetLegacyElo(ctx, u.ID, &legacy); {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return Rating{}, err
+	default:
+		r.Rating, r.Mu = legacy.Rating, float64(legacy.Rating)
+	}
+	return r, nil
+}
+
+// updateRatings pulls current ratings from the store and rates the game of header per places.
+// Returns current ratings and updated ratings in same order as the users of the header.
+func (cl *GameClient[GT, G]) updateRatings(ctx *gin.Context, h *Header, places placesMap) ([]Rating, []Rating, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	us := h.users()
+	oldRatings, err := cl.getRatings(ctx, h.Type, h.NumPlayers, us...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ps := pie.Map(us, func(u *User) int { return places[u.ID] })
+	newRatings := cl.rater.Rate(oldRatings, ps)
+
+	t := time.Now()
+	for i, u := range us {
+		newRatings[i].UID = u.ID
+		newRatings[i].Name = u.Name
+		newRatings[i].EmailHash = u.EmailHash
+		newRatings[i].GravType = u.GravType
+		newRatings[i].Played = oldRatings[i].Played + 1
+		newRatings[i].Won = oldRatings[i].Won
+		if slices.Contains(h.WinnerIDS, u.ID) {
+			newRatings[i].Won++
+		}
+		newRatings[i].Finish = oldRatings[i].Finish + int64(places[u.ID])
+		newRatings[i].WinPercentage = float32(newRatings[i].Won) / float32(newRatings[i].Played)
+		newRatings[i].FinishAvg = float32(newRatings[i].Finish) / float32(newRatings[i].Played)
+		newRatings[i].UpdatedAt = t
+	}
+	return oldRatings, newRatings, nil
+}
+
+func (cl *GameClient[GT, G]) txSaveRatings(tx Tx, ratings []Rating) error {
+	for _, r := range ratings {
+		if err := tx.SetRating(ratingID(r.UID, r.System, r.Type, r.NumPlayers), r); err != nil {
+			return err
+		}
+		if err := tx.AddEloHistory(r.UID, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
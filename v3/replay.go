@@ -0,0 +1,178 @@
+package sn
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// replayEntry provides a view of a game at a committed revision
+type replayEntry[GT any] struct {
+	Rev  Rev
+	Game *GT
+}
+
+// replayHandler streams the committed revisions of a game, in order, as newline delimited JSON.
+// Each revision is filtered through the Viewer interface of the game for the current user,
+// so hidden game information is not leaked.
+// Users that are not players receive delayed spectator views, provided the game permits spectators.
+func (cl *GameClient[GT, G]) replayHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		gid := getID(ctx)
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		last, view, err := cl.viewerFor(cu, index)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		from, to, err := getRevRange(ctx, last)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Status(http.StatusOK)
+
+		enc := json.NewEncoder(ctx.Writer)
+		for rev := from; rev <= to; rev++ {
+			g, err := cl.getCommittedRev(ctx, gid, rev)
+			if err != nil {
+				Warnf(ctx, "unable to replay rev %d of %s: %v", rev, gid, err)
+				_ = enc.Encode(H{"Rev": rev, "Error": err.Error()})
+				return
+			}
+
+			if err := enc.Encode(replayEntry[GT]{Rev: rev, Game: view(g)}); err != nil {
+				Warnf(ctx, "unable to stream rev %d of %s: %v", rev, gid, err)
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// diffHandler returns a JSON patch (RFC 6902) between two committed revisions of a game.
+// Admins receive a patch between the complete game states, whereas other users receive
+// a patch between their views of the game. Users that are not players receive a patch
+// between delayed spectator views, provided the game permits spectators.
+func (cl *GameClient[GT, G]) diffHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		gid := getID(ctx)
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		last, view, err := cl.viewerFor(cu, index)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		from, to, err := getRevRange(ctx, last)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		g1, err := cl.getCommittedRev(ctx, gid, from)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		g2, err := cl.getCommittedRev(ctx, gid, to)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		v1, v2 := view(g1), view(g2)
+		if cu.Admin {
+			v1, v2 = hideSeed(g1, (*GT)(g1)), hideSeed(g2, (*GT)(g2))
+		}
+
+		patch, err := Diff(v1, v2)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"From": from, "To": to, "Patch": patch})
+	}
+}
+
+// getCommittedRev returns the game at the committed revision rev, with the stack of the game set to such revision.
+func (cl *GameClient[GT, G]) getCommittedRev(ctx *gin.Context, gid string, rev Rev) (G, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	g, err := cl.getRev(ctx, gid, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	g.setStack(&Stack{Current: rev, Updated: rev, Committed: rev, UpdateEnd: rev, CommitEnd: rev})
+	return g, nil
+}
+
+// getRevRange returns the revisions provided by the from and to query parameters.
+// If not provided, from defaults to 0 and to defaults to last, the last committed revision.
+func getRevRange(ctx *gin.Context, last Rev) (Rev, Rev, error) {
+	from, err := getRevQuery(ctx, "from", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := getRevQuery(ctx, "to", last)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if from < 0 || to > last || from > to {
+		return 0, 0, fmt.Errorf("invalid revision range %d to %d: %w", from, to, ErrValidation)
+	}
+	return from, to, nil
+}
+
+func getRevQuery(ctx *gin.Context, key string, def Rev) (Rev, error) {
+	s, found := ctx.GetQuery(key)
+	if !found || s == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s revision %q: %w", key, s, ErrValidation)
+	}
+	return Rev(i), nil
+}
@@ -0,0 +1,369 @@
+package sn
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// webhookClient provides the client used to post events to webhooks of users.
+// Connections to addresses other than public addresses are refused when dialed, such that webhooks,
+// including those redirected or whose host resolves differently than when validated, cannot reach internal services.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// sharedAddressSpace provides the carrier-grade NAT range (RFC 6598), which netip does not consider private
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublicAddr returns true if the address is neither loopback, private, link-local, multicast, nor unspecified
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// dialPublicOnly refuses connections to addresses other than public addresses
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddr(addr) {
+		return fmt.Errorf("webhook address %s is not a public address", addr)
+	}
+	return nil
+}
+
+// validateWebhookURL returns a validation error unless the url is an https url whose host resolves only to public addresses
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("webhook url must be an https url: %w", ErrValidation)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("unable to resolve webhook host %q: %w", u.Hostname(), ErrValidation)
+	}
+
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return fmt.Errorf("webhook host %q must resolve to a public address: %w", u.Hostname(), ErrValidation)
+		}
+	}
+	return nil
+}
+
+// recipient provides a user notified of an event
+type recipient struct {
+	UID   UID
+	Name  string
+	Email string
+	// EmailNotifications and EmailReminders provide the email settings of the user,
+	// from which the default preferences of the user derive
+	EmailNotifications bool
+	EmailReminders     bool
+}
+
+// recipientFor returns the user as a recipient
+func recipientFor(u *User) recipient {
+	return recipient{
+		UID:                u.ID,
+		Name:               u.Name,
+		Email:              u.Email,
+		EmailNotifications: u.EmailNotifications,
+		EmailReminders:     u.EmailReminders,
+	}
+}
+
+// recipientsFor returns the recipients associated with the player ids of the header
+func recipientsFor(h *Header, pids []PID) []recipient {
+	rs := make([]recipient, len(pids))
+	for i, pid := range pids {
+		rs[i] = recipient{
+			UID:                h.UIDFor(pid),
+			Name:               h.NameFor(pid),
+			Email:              h.EmailFor(pid),
+			EmailNotifications: h.EmailNotificationsFor(pid),
+			EmailReminders:     h.EmailRemindersFor(pid),
+		}
+	}
+	return rs
+}
+
+// eventNotification returns a notification of the event to the recipients.
+// Upon delivery, the event is routed to the channels of each recipient per the preferences of the recipient,
+// with content rendered from the templates for the event and game type using the data.
+func eventNotification(event NotificationEvent, t Type, gid string, rs []recipient, data H) notification {
+	return notification{
+		Kind:       EventNotification,
+		Event:      event,
+		GameType:   t,
+		GID:        gid,
+		Recipients: rs,
+		Data:       data,
+	}
+}
+
+// contentFor renders the content of the event of the notification for the recipient in the language of the recipient
+func (cl *Client) contentFor(n notification, r recipient, language string) (content, error) {
+	data := H{"GID": n.GID, "Name": r.Name}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	return cl.templates.render(language, n.GameType, string(n.Event), data)
+}
+
+// email returns the email of the content to the recipient
+func (c content) email(r recipient) Email {
+	return Email{
+		ToEmail:  r.Email,
+		ToName:   r.Name,
+		Subject:  cmp.Or(c.Subject, fmt.Sprintf("SlothNinja Games: %s", c.Title)),
+		TextPart: c.Text,
+		HTMLPart: c.HTML,
+	}
+}
+
+// route replaces the event notification having the id with a notification for each channel of each recipient.
+// Notifications for recipients within quiet hours are held until the end of quiet hours, and email notifications
+// for recipients in digest mode are added to the digest of the recipient.
+func (cl *GameClient[GT, G]) route(ctx context.Context, id string, n notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	now := time.Now()
+	type digested struct {
+		r recipient
+		c content
+	}
+
+	var (
+		ns      []notification
+		digests []digested
+		prefs   = make(map[UID]*preferences)
+	)
+
+	for _, r := range n.Recipients {
+		p, err := cl.getPreferences(ctx, r)
+		if err != nil {
+			return err
+		}
+		prefs[r.UID] = p
+
+		if len(p.Channels[n.Event]) == 0 {
+			continue
+		}
+
+		c, err := cl.contentFor(n, r, p.Language)
+		if err != nil {
+			return err
+		}
+
+		held := p.quietUntil(now)
+		for _, ch := range p.Channels[n.Event] {
+			var routed notification
+			switch ch {
+			case PushChannel:
+				routed = pushNotification(n.GID, []UID{r.UID}, c.Title, c.Text)
+			case WebhookChannel:
+				routed = webhookNotification(p.WebhookURL, n, r.UID, c)
+			case EmailChannel:
+				if r.Email == "" {
+					continue
+				}
+				if p.Digest {
+					digests = append(digests, digested{r, c})
+					continue
+				}
+				routed = emailNotification(n.GID, c.email(r))
+			default:
+				continue
+			}
+			routed.NextAttemptAt = held
+			ns = append(ns, routed)
+		}
+	}
+
+	return cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+		ds := make([]*digest, len(digests))
+		for i, dd := range digests {
+			r := dd.r
+			d := new(digest)
+			if err := tx.GetDigest(r.UID, d); errors.Is(err, ErrNotFound) {
+				d = &digest{UID: r.UID, SendAt: prefs[r.UID].nextDigest(now)}
+			} else if err != nil {
+				return err
+			}
+
+			d.Name, d.Email, d.Language = r.Name, r.Email, prefs[r.UID].Language
+			d.Items = append(d.Items, digestItem{
+				Event:     n.Event,
+				GID:       n.GID,
+				Title:     dd.c.Title,
+				Body:      dd.c.Text,
+				CreatedAt: n.CreatedAt,
+			})
+			ds[i] = d
+		}
+
+		for _, d := range ds {
+			if err := tx.SetDigest(d.UID, d); err != nil {
+				return err
+			}
+		}
+
+		if err := cl.txNotify(tx, ns...); err != nil {
+			return err
+		}
+		return tx.DeleteOutboxEntry(id)
+	})
+}
+
+// webhookNotification returns a notification posting the event of notification n, with the content, for the user to the webhook url
+func webhookNotification(url string, n notification, uid UID, c content) notification {
+	return notification{
+		Kind:       WebhookNotification,
+		Event:      n.Event,
+		GID:        n.GID,
+		UserIDS:    []UID{uid},
+		Title:      c.Title,
+		Body:       c.Text,
+		WebhookURL: url,
+	}
+}
+
+// sendWebhook posts the event of the notification, as json, to the webhook url of the notification.
+// Responses other than 2xx are treated as failures.
+func sendWebhook(ctx context.Context, n *notification) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	body, err := json.Marshal(struct {
+		Event     NotificationEvent
+		GID       string
+		UserIDS   []UID
+		Title     string
+		Body      string
+		CreatedAt time.Time
+	}{n.Event, n.GID, n.UserIDS, n.Title, n.Body, n.CreatedAt})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// digest provides the events collected for a user in digest mode
+type digest struct {
+	UID      UID
+	Name     string
+	Email    string
+	Language string
+	Items    []digestItem
+	SendAt   time.Time
+}
+
+type digestItem struct {
+	Event     NotificationEvent
+	GID       string
+	Title     string
+	Body      string
+	CreatedAt time.Time
+}
+
+// email returns the digest email, rendered from the digest template in the language of the user
+func (d *digest) email(r *templateRegistry) (Email, error) {
+	c, err := r.render(d.Language, NoType, "digest", H{"Name": d.Name, "Items": d.Items})
+	if err != nil {
+		return Email{}, err
+	}
+	return c.email(recipient{UID: d.UID, Name: d.Name, Email: d.Email}), nil
+}
+
+// sendDigests replaces the digests due for sending with email notifications
+func (cl *GameClient[GT, G]) sendDigests(ctx context.Context) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	now := time.Now()
+	docs, err := cl.store.ListDigests(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		var d digest
+		if err := doc.DataTo(&d); err != nil {
+			return err
+		}
+
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			var current digest
+			if err := tx.GetDigest(d.UID, &current); errors.Is(err, ErrNotFound) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if current.SendAt.After(now) {
+				return nil
+			}
+
+			email, err := current.email(cl.templates)
+			if err != nil {
+				return err
+			}
+
+			if err := cl.txNotify(tx, emailNotification("", email)); err != nil {
+				return err
+			}
+			return tx.DeleteDigest(d.UID)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
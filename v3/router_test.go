@@ -0,0 +1,74 @@
+package sn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		want bool
+	}{
+		{addr: "8.8.8.8", want: true},
+		{addr: "2001:4860:4860::8888", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fd00::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "100.64.0.1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "224.0.0.1"},
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(tc.addr)); got != tc.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tc.addr, got, tc.want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	ctx := context.Background()
+	for _, rawURL := range []string{
+		"http://8.8.8.8/hook",
+		"https:///hook",
+		"https://127.0.0.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]:8443/hook",
+		"https://10.0.0.1/hook",
+	} {
+		if err := validateWebhookURL(ctx, rawURL); !errors.Is(err, ErrValidation) {
+			t.Errorf("validateWebhookURL(%q) = %v, want ErrValidation", rawURL, err)
+		}
+	}
+
+	if err := validateWebhookURL(ctx, "https://8.8.8.8/hook"); err != nil {
+		t.Errorf("validateWebhookURL(public) = %v, want nil", err)
+	}
+}
+
+func TestSendWebhookRefusesNonPublicAddress(t *testing.T) {
+	var called bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	err := sendWebhook(context.Background(), &notification{Kind: WebhookNotification, WebhookURL: srv.URL})
+	if err == nil {
+		t.Error("sendWebhook to loopback address succeeded, want error")
+	}
+
+	if called {
+		t.Error("webhook on loopback address was called")
+	}
+}
@@ -0,0 +1,373 @@
+package sn
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/elliotchance/pie/v2"
+)
+
+// table provides the seating and results of a game of a tournament round
+type table struct {
+	Round   int
+	Table   int
+	GID     string
+	UserIDS []UID
+	// Places provides the places of the users of the table, in the same order as UserIDS.
+	// Places is nil until the game of the table ends.
+	Places []int
+	Status Status
+}
+
+// bye provides a user sitting out a tournament round.
+// For purposes of standings, a bye counts as a win.
+type bye struct {
+	Round int
+	UID   UID
+}
+
+// standing provides the standing of an entrant of a tournament
+type standing struct {
+	UID  UID
+	Name string
+	Rank int
+	// Points provides the sum of game points, where each game awards between 0 (last place)
+	// and 1 (first place) points per the share of opponents placing behind the entrant.
+	Points float64
+	// Buchholz provides the sum of the points of opponents faced (first tie-breaker)
+	Buchholz float64
+	// Wins provides the number of first places (second tie-breaker)
+	Wins int
+	// PlaceAvg provides the average place of games played (third tie-breaker, lower is better)
+	PlaceAvg float64
+	Played   int
+	Byes     int
+	// EliminatedIn provides the round in which the entrant was eliminated from a single-elimination tournament.
+	// Zero indicates the entrant remains in the tournament.
+	EliminatedIn int
+
+	placeSum  int
+	opponents []UID
+}
+
+// seat returns the tables and byes of the next round of the tournament
+func (t *tournament) seat(round int) ([]table, []bye) {
+	switch t.Format {
+	case RoundRobin:
+		return t.seatRoundRobin(round)
+	case SingleElimination:
+		return t.seatElimination(round)
+	default:
+		return t.seatSwiss(round)
+	}
+}
+
+// seatSwiss seats entrants of similar standing at the same table.
+// The first round seats entrants by seed, with the entrants of each table drawn from
+// successive tiers of seeds (e.g., for two player tables, seed 1 plays the first seed of the bottom half).
+// Subsequent rounds seat entrants by standing. For two player tables, rematches are avoided where possible.
+// Byes are provided to the lowest seeded or standing entrants not yet having a bye.
+func (t *tournament) seatSwiss(round int) ([]table, []bye) {
+	order := slices.Clone(t.Seeds)
+	if round > 1 {
+		order = pie.Map(t.Standings, func(s standing) UID { return s.UID })
+	}
+
+	order, byes := t.byesFor(round, order)
+	if round == 1 {
+		return t.tables(round, tiered(order, t.NumPlayers)), byes
+	}
+
+	if t.NumPlayers != 2 {
+		return t.tables(round, chunk(order, t.NumPlayers)), byes
+	}
+	return t.tables(round, t.pairAvoidingRematches(order)), byes
+}
+
+// seatRoundRobin seats entrants per the circle method.
+// For two player tables, each entrant plays each other entrant once over the rounds of the tournament.
+// For larger tables, rotating the circle seats each entrant with differing opponents from round to round,
+// though not every entrant is guaranteed to meet every other entrant.
+func (t *tournament) seatRoundRobin(round int) ([]table, []bye) {
+	circle := slices.Clone(t.Seeds)
+	if t.NumPlayers == 2 && len(circle)%2 == 1 {
+		// zero uid represents a bye
+		circle = append(circle, 0)
+	}
+
+	// fix the first entrant and rotate the remaining entrants one position per round
+	if len(circle) > 2 {
+		rest := circle[1:]
+		k := (round - 1) % len(rest)
+		rest = append(slices.Clone(rest[len(rest)-k:]), rest[:len(rest)-k]...)
+		circle = append(circle[:1], rest...)
+	}
+
+	if t.NumPlayers != 2 {
+		order, byes := t.byesFor(round, circle)
+		return t.tables(round, chunk(order, t.NumPlayers)), byes
+	}
+
+	var (
+		seats [][]UID
+		byes  []bye
+	)
+	for i, n := 0, len(circle); i < n/2; i++ {
+		uid1, uid2 := circle[i], circle[n-1-i]
+		switch {
+		case uid1 == 0:
+			byes = append(byes, bye{Round: round, UID: uid2})
+		case uid2 == 0:
+			byes = append(byes, bye{Round: round, UID: uid1})
+		default:
+			seats = append(seats, []UID{uid1, uid2})
+		}
+	}
+	return t.tables(round, seats), byes
+}
+
+// seatElimination seats the entrants remaining in the tournament by seed, with the entrants of each table
+// drawn from successive tiers of seeds. Byes are provided to the highest seeded entrants.
+// If fewer than NumPlayers entrants remain, the remaining entrants are seated at a single final table,
+// which requires a game supporting the player count, see validateFinalTable.
+func (t *tournament) seatElimination(round int) ([]table, []bye) {
+	eliminated := make(map[UID]bool)
+	for _, s := range t.Standings {
+		if s.EliminatedIn != 0 {
+			eliminated[s.UID] = true
+		}
+	}
+	remaining := pie.Filter(t.Seeds, func(uid UID) bool { return !eliminated[uid] })
+
+	if len(remaining) <= t.NumPlayers {
+		return t.tables(round, [][]UID{remaining}), nil
+	}
+
+	numByes := len(remaining) % t.NumPlayers
+	byes := pie.Map(remaining[:numByes], func(uid UID) bye { return bye{Round: round, UID: uid} })
+	return t.tables(round, tiered(remaining[numByes:], t.NumPlayers)), byes
+}
+
+// byesFor removes the entrants receiving byes from order, such that the number of remaining entrants
+// is a multiple of NumPlayers. Byes are provided to entrants from the end of order not yet having a bye.
+func (t *tournament) byesFor(round int, order []UID) ([]UID, []bye) {
+	numByes := len(order) % t.NumPlayers
+	if numByes == 0 {
+		return order, nil
+	}
+
+	had := make(map[UID]bool)
+	for _, b := range t.Byes {
+		had[b.UID] = true
+	}
+
+	var byes []bye
+	take := func(eligible func(UID) bool) {
+		for i := len(order) - 1; i >= 0 && len(byes) < numByes; i-- {
+			if eligible(order[i]) {
+				byes = append(byes, bye{Round: round, UID: order[i]})
+				order = slices.Delete(order, i, i+1)
+			}
+		}
+	}
+	take(func(uid UID) bool { return !had[uid] })
+	take(func(UID) bool { return true })
+	return order, byes
+}
+
+// pairAvoidingRematches pairs each unpaired entrant, in order, with the next unpaired entrant not yet played.
+// If each remaining entrant has been played, the entrant is paired with the next unpaired entrant.
+func (t *tournament) pairAvoidingRematches(order []UID) [][]UID {
+	played := make(map[[2]UID]bool)
+	for _, tb := range t.Tables {
+		for _, uid1 := range tb.UserIDS {
+			for _, uid2 := range tb.UserIDS {
+				played[[2]UID{uid1, uid2}] = true
+			}
+		}
+	}
+
+	var pairs [][]UID
+	remaining := slices.Clone(order)
+	for len(remaining) > 1 {
+		uid1 := remaining[0]
+		j := slices.IndexFunc(remaining[1:], func(uid2 UID) bool { return !played[[2]UID{uid1, uid2}] }) + 1
+		if j == 0 {
+			j = 1
+		}
+		pairs = append(pairs, []UID{uid1, remaining[j]})
+		remaining = slices.Delete(remaining, j, j+1)[1:]
+	}
+	return pairs
+}
+
+// tables returns the tables of the round for the seats
+func (t *tournament) tables(round int, seats [][]UID) []table {
+	tables := make([]table, len(seats))
+	for i, uids := range seats {
+		tables[i] = table{Round: round, Table: i + 1, UserIDS: uids, Status: Recruiting}
+	}
+	return tables
+}
+
+// chunk seats successive entrants of order at the same table
+func chunk(order []UID, numPlayers int) [][]UID {
+	var seats [][]UID
+	for uids := range slices.Chunk(order, numPlayers) {
+		seats = append(seats, uids)
+	}
+	return seats
+}
+
+// tiered splits order into numPlayers tiers and seats the i-th entrant of each tier at the i-th table
+func tiered(order []UID, numPlayers int) [][]UID {
+	numTables := len(order) / numPlayers
+	seats := make([][]UID, numTables)
+	for i, uid := range order {
+		seats[i%numTables] = append(seats[i%numTables], uid)
+	}
+	return seats
+}
+
+// roundsFor returns the number of rounds of a tournament of the format having numEntrants entrants
+func roundsFor(format TournamentFormat, numEntrants, numPlayers int) int {
+	switch format {
+	case RoundRobin:
+		if numPlayers == 2 {
+			return numEntrants + numEntrants%2 - 1
+		}
+		return int(math.Ceil(float64(numEntrants-1) / float64(numPlayers-1)))
+	case SingleElimination:
+		var rounds int
+		for remaining := numEntrants; remaining > 1; rounds++ {
+			if remaining <= numPlayers {
+				remaining = 1
+			} else {
+				remaining = remaining/numPlayers + remaining%numPlayers
+			}
+		}
+		return rounds
+	default:
+		return int(math.Ceil(math.Log2(float64(numEntrants))))
+	}
+}
+
+// finalTableSize returns the number of entrants seated at the final table of a single-elimination tournament
+func finalTableSize(numEntrants, numPlayers int) int {
+	remaining := numEntrants
+	for remaining > numPlayers {
+		remaining = remaining/numPlayers + remaining%numPlayers
+	}
+	return remaining
+}
+
+// updateStandings recomputes the standings of the tournament from the results of its tables and byes
+func (t *tournament) updateStandings() {
+	byUID := make(map[UID]*standing, len(t.UserIDS))
+	for i, uid := range t.UserIDS {
+		byUID[uid] = &standing{UID: uid, Name: t.UserNames[i]}
+	}
+
+	for _, b := range t.Byes {
+		if s, ok := byUID[b.UID]; ok {
+			s.Points++
+			s.Byes++
+		}
+	}
+
+	for _, tb := range t.Tables {
+		if tb.Places == nil {
+			continue
+		}
+
+		for i, uid := range tb.UserIDS {
+			s, ok := byUID[uid]
+			if !ok {
+				continue
+			}
+
+			var score float64
+			for j, uid2 := range tb.UserIDS {
+				if i != j {
+					score += pairwiseScore(tb.Places[i], tb.Places[j])
+					s.opponents = append(s.opponents, uid2)
+				}
+			}
+			if len(tb.UserIDS) > 1 {
+				s.Points += score / float64(len(tb.UserIDS)-1)
+			}
+
+			s.Played++
+			s.placeSum += tb.Places[i]
+			if tb.Places[i] == 1 {
+				s.Wins++
+			}
+
+			if t.Format == SingleElimination && uid != tb.winner() {
+				s.EliminatedIn = tb.Round
+			}
+		}
+	}
+
+	seeds := make(map[UID]int, len(t.Seeds))
+	for i, uid := range t.Seeds {
+		seeds[uid] = i
+	}
+
+	standings := make([]standing, 0, len(byUID))
+	for _, uid := range t.UserIDS {
+		s := byUID[uid]
+		for _, opp := range s.opponents {
+			if o, ok := byUID[opp]; ok {
+				s.Buchholz += o.Points
+			}
+		}
+		if s.Played > 0 {
+			s.PlaceAvg = float64(s.placeSum) / float64(s.Played)
+		}
+		standings = append(standings, *s)
+	}
+
+	slices.SortStableFunc(standings, func(s1, s2 standing) int {
+		if t.Format == SingleElimination {
+			// entrants remaining rank ahead of eliminated entrants, and later eliminations rank ahead of earlier
+			if remaining1, remaining2 := s1.EliminatedIn == 0, s2.EliminatedIn == 0; remaining1 != remaining2 {
+				if remaining1 {
+					return -1
+				}
+				return 1
+			}
+			if c := cmp.Compare(s1.EliminatedIn, s2.EliminatedIn); c != 0 {
+				return -c
+			}
+		}
+		if c := cmp.Compare(s1.Points, s2.Points); c != 0 {
+			return -c
+		}
+		if c := cmp.Compare(s1.Buchholz, s2.Buchholz); c != 0 {
+			return -c
+		}
+		if c := cmp.Compare(s1.Wins, s2.Wins); c != 0 {
+			return -c
+		}
+		if c := cmp.Compare(s1.PlaceAvg, s2.PlaceAvg); c != 0 {
+			return c
+		}
+		return cmp.Compare(seeds[s1.UID], seeds[s2.UID])
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	t.Standings = standings
+}
+
+// winner returns the user advancing from the table of a single-elimination tournament.
+// Ties for first place are broken in favor of the higher seed, which is seated first.
+func (tb table) winner() UID {
+	if len(tb.Places) == 0 {
+		return 0
+	}
+	return tb.UserIDS[slices.Index(tb.Places, slices.Min(tb.Places))]
+}
@@ -0,0 +1,244 @@
+package sn
+
+import (
+	"slices"
+	"testing"
+)
+
+func uidsTo(n int) []UID {
+	uids := make([]UID, n)
+	for i := range uids {
+		uids[i] = UID(i + 1)
+	}
+	return uids
+}
+
+func seatsOf(tables []table) [][]UID {
+	seats := make([][]UID, len(tables))
+	for i, tb := range tables {
+		seats[i] = tb.UserIDS
+	}
+	return seats
+}
+
+func byeUIDS(byes []bye) []UID {
+	uids := make([]UID, len(byes))
+	for i, b := range byes {
+		uids[i] = b.UID
+	}
+	return uids
+}
+
+// checkSeated fails unless each entrant is seated at exactly one table or has a bye, and each table is full
+func checkSeated(t *testing.T, tr *tournament, entrants []UID, tables []table, byes []bye) {
+	t.Helper()
+
+	seen := make(map[UID]int)
+	for _, tb := range tables {
+		if len(tb.UserIDS) != tr.NumPlayers {
+			t.Errorf("table %d seats %d, want %d", tb.Table, len(tb.UserIDS), tr.NumPlayers)
+		}
+		for _, uid := range tb.UserIDS {
+			seen[uid]++
+		}
+	}
+	for _, b := range byes {
+		seen[b.UID]++
+	}
+
+	for _, uid := range entrants {
+		if seen[uid] != 1 {
+			t.Errorf("entrant %d seated %d times, want 1", uid, seen[uid])
+		}
+	}
+}
+
+func TestSeatSwissFirstRound(t *testing.T) {
+	tr := &tournament{Format: Swiss, Header: Header{NumPlayers: 2}, Seeds: uidsTo(9)}
+	tables, byes := tr.seat(1)
+	checkSeated(t, tr, tr.Seeds, tables, byes)
+
+	// the lowest seed sits out, and each remaining seed plays the corresponding seed of the bottom half
+	want := [][]UID{{1, 5}, {2, 6}, {3, 7}, {4, 8}}
+	if got := seatsOf(tables); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+
+	if got := byeUIDS(byes); !slices.Equal(got, []UID{9}) {
+		t.Errorf("byes = %v, want [9]", got)
+	}
+}
+
+func TestSeatSwissAvoidsRematches(t *testing.T) {
+	// drawn first round games leave the entrants tied, such that seating by standing alone would repeat pairings
+	tr := &tournament{
+		Format: Swiss,
+		Header: Header{NumPlayers: 2, UserIDS: uidsTo(4), UserNames: []string{"u1", "u2", "u3", "u4"}},
+		Seeds:  uidsTo(4),
+		Tables: []table{
+			{Round: 1, Table: 1, UserIDS: []UID{1, 2}, Places: []int{1, 1}},
+			{Round: 1, Table: 2, UserIDS: []UID{3, 4}, Places: []int{1, 1}},
+		},
+	}
+	tr.updateStandings()
+
+	tables, byes := tr.seat(2)
+	checkSeated(t, tr, tr.Seeds, tables, byes)
+
+	for _, tb := range tables {
+		for _, prior := range tr.Tables[:2] {
+			if slices.Equal(slices.Sorted(slices.Values(tb.UserIDS)), prior.UserIDS) {
+				t.Errorf("round 2 repeats pairing %v", prior.UserIDS)
+			}
+		}
+	}
+}
+
+func TestSeatSwissSpreadsByes(t *testing.T) {
+	tr := &tournament{
+		Format: Swiss,
+		Header: Header{NumPlayers: 2},
+		Seeds:  uidsTo(3),
+		Byes:   []bye{{Round: 1, UID: 3}},
+	}
+
+	order, byes := tr.byesFor(2, slices.Clone(tr.Seeds))
+	if got := byeUIDS(byes); !slices.Equal(got, []UID{2}) {
+		t.Errorf("byes = %v, want [2]", got)
+	}
+
+	if !slices.Equal(order, []UID{1, 3}) {
+		t.Errorf("order = %v, want [1 3]", order)
+	}
+}
+
+func TestSeatRoundRobinPairsEachEntrantOnce(t *testing.T) {
+	for _, n := range []int{4, 5, 6, 7} {
+		tr := &tournament{Format: RoundRobin, Header: Header{NumPlayers: 2}, Seeds: uidsTo(n)}
+		rounds := roundsFor(RoundRobin, n, 2)
+
+		played := make(map[[2]UID]int)
+		for round := 1; round <= rounds; round++ {
+			tables, byes := tr.seat(round)
+			checkSeated(t, tr, tr.Seeds, tables, byes)
+
+			for _, tb := range tables {
+				uid1, uid2 := min(tb.UserIDS[0], tb.UserIDS[1]), max(tb.UserIDS[0], tb.UserIDS[1])
+				played[[2]UID{uid1, uid2}]++
+			}
+		}
+
+		if want := n * (n - 1) / 2; len(played) != want {
+			t.Errorf("%d entrants: %d pairings played, want %d", n, len(played), want)
+		}
+		for pair, count := range played {
+			if count != 1 {
+				t.Errorf("%d entrants: %v played %d times, want 1", n, pair, count)
+			}
+		}
+	}
+}
+
+func TestSeatRoundRobinLargerTables(t *testing.T) {
+	tr := &tournament{Format: RoundRobin, Header: Header{NumPlayers: 3}, Seeds: uidsTo(7)}
+	for round := 1; round <= roundsFor(RoundRobin, 7, 3); round++ {
+		tables, byes := tr.seat(round)
+		checkSeated(t, tr, tr.Seeds, tables, byes)
+
+		if len(byes) != 1 {
+			t.Errorf("round %d: %d byes, want 1", round, len(byes))
+		}
+	}
+}
+
+func TestSeatElimination(t *testing.T) {
+	tr := &tournament{Format: SingleElimination, Header: Header{NumPlayers: 2}, Seeds: uidsTo(7)}
+	tables, byes := tr.seat(1)
+	checkSeated(t, tr, tr.Seeds, tables, byes)
+
+	// the top seed receives the bye
+	if got := byeUIDS(byes); !slices.Equal(got, []UID{1}) {
+		t.Errorf("byes = %v, want [1]", got)
+	}
+
+	want := [][]UID{{2, 5}, {3, 6}, {4, 7}}
+	if got := seatsOf(tables); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+}
+
+func TestSeatEliminationFinalTable(t *testing.T) {
+	tr := &tournament{
+		Format:    SingleElimination,
+		Header:    Header{NumPlayers: 3},
+		Seeds:     uidsTo(5),
+		Standings: []standing{{UID: 1}, {UID: 2}, {UID: 3, EliminatedIn: 1}, {UID: 4}, {UID: 5, EliminatedIn: 1}},
+	}
+
+	tables, byes := tr.seat(2)
+	if len(byes) != 0 {
+		t.Errorf("byes = %v, want none", byeUIDS(byes))
+	}
+
+	// fewer than NumPlayers entrants remain, so they are seated at a single final table
+	want := [][]UID{{1, 2, 4}}
+	if got := seatsOf(tables); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+
+	tr.Standings[3].EliminatedIn = 2
+	tables, _ = tr.seat(3)
+	if got := seatsOf(tables); !slices.EqualFunc(got, [][]UID{{1, 2}}, slices.Equal) {
+		t.Errorf("tables = %v, want [[1 2]]", got)
+	}
+}
+
+func TestFinalTableSize(t *testing.T) {
+	for _, tc := range []struct {
+		entrants, players, want int
+	}{
+		{entrants: 8, players: 2, want: 2},
+		{entrants: 9, players: 3, want: 3},
+		{entrants: 4, players: 3, want: 2},
+		{entrants: 5, players: 2, want: 2},
+		{entrants: 10, players: 4, want: 4},
+		{entrants: 3, players: 4, want: 3},
+	} {
+		if got := finalTableSize(tc.entrants, tc.players); got != tc.want {
+			t.Errorf("finalTableSize(%d, %d) = %d, want %d", tc.entrants, tc.players, got, tc.want)
+		}
+	}
+}
+
+func TestRoundsFor(t *testing.T) {
+	for _, tc := range []struct {
+		format            TournamentFormat
+		entrants, players int
+		want              int
+	}{
+		{format: Swiss, entrants: 8, players: 2, want: 3},
+		{format: Swiss, entrants: 9, players: 2, want: 4},
+		{format: RoundRobin, entrants: 4, players: 2, want: 3},
+		{format: RoundRobin, entrants: 5, players: 2, want: 5},
+		{format: RoundRobin, entrants: 7, players: 3, want: 3},
+		{format: SingleElimination, entrants: 8, players: 2, want: 3},
+		{format: SingleElimination, entrants: 7, players: 2, want: 3},
+		{format: SingleElimination, entrants: 9, players: 3, want: 2},
+		{format: SingleElimination, entrants: 4, players: 3, want: 2},
+	} {
+		if got := roundsFor(tc.format, tc.entrants, tc.players); got != tc.want {
+			t.Errorf("roundsFor(%s, %d, %d) = %d, want %d", tc.format, tc.entrants, tc.players, got, tc.want)
+		}
+	}
+}
+
+func TestTournamentPublicHidesSeed(t *testing.T) {
+	tr := &tournament{Header: Header{Seed: 42, CreatorEmail: "user1@example.com"}}
+	if p := tr.public(); p.Seed != 0 || p.CreatorEmail != "" {
+		t.Errorf("public Seed = %d, CreatorEmail = %q, want both removed", p.Seed, p.CreatorEmail)
+	}
+
+	if tr.Seed != 42 {
+		t.Errorf("Seed = %d, want the tournament unchanged", tr.Seed)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"os"
 
-	"firebase.google.com/go/v4/messaging"
+	"github.com/elliotchance/pie/v2"
 	"github.com/mailjet/mailjet-apiv3-go"
 )
 
@@ -12,7 +12,9 @@ func getMJKeys() (string, string) {
 	return os.Getenv("MJ_API_KEY_PUB"), os.Getenv("MJ_API_KEY_PRIV")
 }
 
-// SendMessages sends email messages
+// SendMessages sends email messages via Mailjet in production, and otherwise only logs them.
+//
+// Deprecated: Use a Mailer, set via WithMailer.
 func SendMessages(ctx context.Context, msgInfo ...mailjet.InfoMessagesV31) (*mailjet.ResultsV31, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
@@ -29,33 +31,54 @@ func SendMessages(ctx context.Context, msgInfo ...mailjet.InfoMessagesV31) (*mai
 	return nil, nil
 }
 
-func (cl *GameClient[GT, G]) sendNotifications(ctx context.Context, g G, pids []PID) (*messaging.BatchResponse, error) {
+const notificationImageURL = "https://www.slothninja.com/public/logo.png"
+
+// turnNotification returns a notification informing the users associated with the player ids of their turn
+func turnNotification[GT any, G Gamer[GT]](g G, pids []PID) notification {
+	h := g.header()
+	return eventNotification(YourTurnEvent, h.Type, g.id(), recipientsFor(h, pids), H{"Game": h.Title})
+}
+
+// startedNotification returns a notification informing all players of the start of the game
+func startedNotification[GT any, G Gamer[GT]](g G) notification {
+	h := g.header()
+	return eventNotification(GameStartedEvent, h.Type, g.id(), recipientsFor(h, h.allPIDS()), H{
+		"Game":         h.Title,
+		"StartPlayers": pie.Map(h.CPIDS, h.NameFor),
+		"Match":        false,
+	})
+}
+
+// filledNotification returns a notification informing the creator of the invitation of the game that the invitation filled
+func filledNotification[GT any, G Gamer[GT]](g G) notification {
+	h := g.header()
+	return eventNotification(InvitationFilledEvent, h.Type, g.id(), []recipient{h.creatorRecipient()}, H{"Game": h.Title})
+}
+
+// creatorRecipient returns the creator of the invitation of the header as a recipient
+func (h *Header) creatorRecipient() recipient {
+	return recipient{
+		UID:                h.CreatorID,
+		Name:               h.CreatorName,
+		Email:              h.CreatorEmail,
+		EmailNotifications: h.CreatorEmailNotifications,
+	}
+}
+
+// joinedNotification returns a notification, in the language of the user, thanking the user for joining the game of the invitation
+func (cl *GameClient[GT, G]) joinedNotification(ctx context.Context, cu *User, inv *invitation) (notification, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	if len(pids) == 0 {
-		return nil, nil
+	r := recipientFor(cu)
+	p, err := cl.getPreferences(ctx, r)
+	if err != nil {
+		return notification{}, err
 	}
 
-	tokens, err := cl.getTokenStrings(ctx, g.id(), g.UIDSForPIDS(pids))
+	c, err := cl.templates.render(p.Language, inv.Type, "invitation-joined", H{"GID": inv.id(), "Name": r.Name, "Game": inv.Title})
 	if err != nil {
-		return nil, err
-	}
-	if len(tokens) < 1 {
-		return nil, nil
-	}
-	notifications := &messaging.MulticastMessage{
-		Tokens: tokens,
-		Notification: &messaging.Notification{
-			Title:    "It is your turn at SlothNinja Games",
-			Body:     "One or more games await your move.",
-			ImageURL: "https://www.slothninja.com/public/logo.png",
-		},
-		Webpush: &messaging.WebpushConfig{
-			FCMOptions: &messaging.WebpushFCMOptions{
-				Link: "https://www.slothninja.com",
-			},
-		},
+		return notification{}, err
 	}
-	return cl.FCM.SendEachForMulticast(ctx, notifications)
+	return topicNotification(cu.ID.toString(), c.Title, c.Text), nil
 }
@@ -0,0 +1,138 @@
+package sn
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSpectatorDelay provides the largest number of revisions by which spectator views may be delayed
+const maxSpectatorDelay = 100
+
+// SpectatorViewer provides an optional hook for games to provide the view of spectators.
+// The spectator view should strip all private information of all players.
+// Games that do not implement SpectatorViewer provide spectators the view returned by ViewFor(0).
+type SpectatorViewer[T any] interface {
+	SpectatorView() *T
+}
+
+func (h *Header) setSpectators(allow bool, delay int) error {
+	if delay < 0 || delay > maxSpectatorDelay {
+		return fmt.Errorf("spectator delay must be between 0 and %d revisions: %w", maxSpectatorDelay, ErrValidation)
+	}
+
+	h.AllowSpectators = allow
+	h.SpectatorDelay = 0
+	if allow {
+		h.SpectatorDelay = delay
+	}
+	return nil
+}
+
+// isPlayer returns true if the user is a player of the game
+func (h *Header) isPlayer(uid UID) bool {
+	return slices.Contains(h.UserIDS, uid)
+}
+
+// canSpectate returns an error unless the game may be viewed by spectators.
+// Games no longer running may be viewed by any user.
+func (h *Header) canSpectate() error {
+	if h.Status == Running && !h.AllowSpectators {
+		return ErrNoSpectators
+	}
+	return nil
+}
+
+// spectatorRev returns the last revision viewable by spectators, given the last committed revision.
+// Views of running games are delayed by SpectatorDelay revisions.
+func (h *Header) spectatorRev(last Rev) Rev {
+	if h.Status != Running {
+		return last
+	}
+	return max(last-Rev(h.SpectatorDelay), 0)
+}
+
+// spectatorView returns the view of the game for spectators, with the seed of the game and emails of users removed
+func (cl *GameClient[GT, G]) spectatorView(g G) *GT {
+	if sv, ok := any(g).(SpectatorViewer[GT]); ok {
+		return hideEmails[GT, G](hideSeed(g, sv.SpectatorView()))
+	}
+	return hideEmails[GT, G](cl.viewFor(g, 0))
+}
+
+// hideEmails removes the emails, and the hashes thereof, of the creator, users, and invitees from view v.
+// Hashes are removed, as the emails of spectated users may be recovered from them.
+// As hideSeed copies views that are the game itself, v must be a view returned by hideSeed.
+func hideEmails[GT any, G Gamer[GT]](v *GT) *GT {
+	if v == nil {
+		return nil
+	}
+
+	h := G(v).header()
+	h.CreatorEmail, h.CreatorEmailHash = "", ""
+	h.UserEmails = make([]string, len(h.UserEmails))
+	h.UserEmailHashes = make([]string, len(h.UserEmailHashes))
+	h.InviteeEmails = make([]string, len(h.InviteeEmails))
+	return v
+}
+
+// spectator returns true if the user views the game as a spectator, i.e., is neither a player nor an admin
+func (i *index) spectator(cu *User) bool {
+	return !cu.Admin && !i.isPlayer(cu.ID)
+}
+
+// viewerFor returns the last committed revision of the game viewable by the user,
+// and a func returning views of the game for the user.
+// Players and admins view all committed revisions, whereas spectators view delayed spectator views.
+func (cl *GameClient[GT, G]) viewerFor(cu *User, i *index) (Rev, func(G) *GT, error) {
+	if !i.spectator(cu) {
+		return i.Rev, func(g G) *GT { return cl.viewFor(g, cu.ID) }, nil
+	}
+
+	if err := i.canSpectate(); err != nil {
+		return 0, nil, err
+	}
+	return i.spectatorRev(i.Rev), cl.spectatorView, nil
+}
+
+// spectateHandler returns the spectator view of a game to a logged in user that is not a player of the game.
+func (cl *GameClient[GT, G]) spectateHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		gid := getID(ctx)
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if index.isPlayer(cu.ID) {
+			JErr(ctx, fmt.Errorf("players may not spectate their own game: %w", ErrValidation))
+			return
+		}
+
+		if err := index.canSpectate(); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		rev := index.spectatorRev(index.Rev)
+		g, err := cl.getCommittedRev(ctx, gid, rev)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Game": cl.spectatorView(g), "Rev": rev, "Delay": index.Rev - rev})
+	}
+}
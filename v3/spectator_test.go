@@ -0,0 +1,26 @@
+package sn
+
+import "testing"
+
+func TestHideEmails(t *testing.T) {
+	g := new(testGame)
+	h := g.header()
+	h.addCreator(&User{ID: 1, userData: userData{Email: "user1@example.com", EmailHash: "hash1"}})
+	h.addUser(&User{ID: 1, userData: userData{Email: "user1@example.com", EmailHash: "hash1"}})
+	h.addUser(&User{ID: 2, userData: userData{Email: "user2@example.com", EmailHash: "hash2"}})
+
+	h = hideEmails[testGame](g).header()
+	if h.CreatorEmail != "" || h.CreatorEmailHash != "" {
+		t.Errorf("CreatorEmail = %q, CreatorEmailHash = %q, want both removed", h.CreatorEmail, h.CreatorEmailHash)
+	}
+
+	for i := range h.UserIDS {
+		if h.UserEmails[i] != "" || h.UserEmailHashes[i] != "" {
+			t.Errorf("user %d: UserEmails = %q, UserEmailHashes = %q, want both removed", i, h.UserEmails[i], h.UserEmailHashes[i])
+		}
+	}
+
+	if len(h.UserEmailHashes) != len(h.UserIDS) {
+		t.Errorf("len(UserEmailHashes) = %d, want %d", len(h.UserEmailHashes), len(h.UserIDS))
+	}
+}
@@ -3,8 +3,6 @@ package sn
 import (
 	"context"
 	"strconv"
-
-	"cloud.google.com/go/firestore"
 )
 
 type Rev int
@@ -22,9 +20,42 @@ type Stack struct {
 	CommitEnd Rev
 }
 
+// StackState provides the undo stack of a user, along with the stack operations presently permitted
+type StackState struct {
+	Current   Rev
+	Updated   Rev
+	Committed Rev
+	CanUndo   bool
+	CanRedo   bool
+	CanReset  bool
+}
+
+func (s *Stack) state() StackState {
+	return StackState{
+		Current:   s.Current,
+		Updated:   s.Updated,
+		Committed: s.Committed,
+		CanUndo:   s.canUndo(),
+		CanRedo:   s.canRedo(),
+		CanReset:  s.canReset(),
+	}
+}
+
+func (s *Stack) canUndo() bool {
+	return s.Current > s.Committed
+}
+
+func (s *Stack) canRedo() bool {
+	return s.Updated > s.Committed && s.Current < s.Updated
+}
+
+func (s *Stack) canReset() bool {
+	return s.Current != s.Committed || s.Updated != s.Current
+}
+
 // undo updates stack to undo an action
 func (s *Stack) undo() bool {
-	undo := s.Current > s.Committed
+	undo := s.canUndo()
 	if undo {
 		s.Current--
 	}
@@ -40,7 +71,7 @@ func (s *Stack) update() {
 
 // reset resets the stack to the last committed action
 func (s *Stack) reset() bool {
-	reset := s.Current != s.Committed || s.Updated != s.Current
+	reset := s.canReset()
 	if reset {
 		s.Current, s.Updated = s.Committed, s.Committed
 	}
@@ -49,7 +80,7 @@ func (s *Stack) reset() bool {
 
 // redo moves the undo stack forward
 func (s *Stack) redo() bool {
-	redo := s.Updated > s.Committed && s.Current < s.Updated
+	redo := s.canRedo()
 	if redo {
 		s.Current++
 	}
@@ -100,28 +131,13 @@ func (s *Stack) rollforward(rev Rev) bool {
 	return rollforward
 }
 
-func (cl *GameClient[GT, G]) stackCollectionRef() *firestore.CollectionRef {
-	return cl.FS.Collection("Stack")
-}
-
-func (cl *GameClient[GT, G]) stackDocRef(gid string, uid UID) *firestore.DocumentRef {
-	return cl.stackCollectionRef().Doc(gid).Collection("For").Doc(uid.toString())
-}
-
 func (cl *GameClient[GT, G]) getStack(ctx context.Context, gid string, uid UID) (*Stack, error) {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.stackDocRef(gid, uid).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	stack := new(Stack)
-	err = snap.DataTo(stack)
-	if err != nil {
+	if err := cl.store.GetStack(ctx, gid, uid, stack); err != nil {
 		return nil, err
 	}
-
 	return stack, nil
 }
@@ -0,0 +1,223 @@
+package sn
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Store provides the persistence used by a GameClient.
+// Getters decode the stored document into the provided destination.
+// If the document does not exist, getters return an error wrapping ErrNotFound.
+type Store interface {
+	// RunTransaction runs f within a transaction.
+	// Writes made via the provided Tx are applied only if f returns nil.
+	RunTransaction(context.Context, func(context.Context, Tx) error) error
+
+	GetRev(context.Context, string, Rev, any) error
+	GetCached(context.Context, string, UID, Rev, any) error
+	GetStack(context.Context, string, UID, *Stack) error
+	GetIndex(context.Context, string, any) error
+	ListIndexes(context.Context, IndexQuery) ([]Doc, error)
+
+	GetInvitation(context.Context, string, any) error
+	SetInvitation(context.Context, string, any) error
+	GetHash(context.Context, string) ([]byte, error)
+	// ListExpiredInvitations lists the invitations having an ExpiresAt at or before due
+	ListExpiredInvitations(ctx context.Context, due time.Time) ([]Doc, error)
+	// ListInvites lists the invitations to which the user was invited directly
+	ListInvites(ctx context.Context, uid UID) ([]Doc, error)
+	// ListInvitations lists the invitations matching the query, newest first
+	ListInvitations(context.Context, InvitationQuery) ([]Doc, error)
+
+	GetTournament(context.Context, string, any) error
+	ListQueue(context.Context, Type) ([]Doc, error)
+
+	// ListOutbox lists at most limit pending outbox entries due for delivery at or before due
+	ListOutbox(ctx context.Context, due time.Time, limit int) ([]Doc, error)
+	// ListDigests lists the digests due for sending at or before due
+	ListDigests(ctx context.Context, due time.Time) ([]Doc, error)
+
+	GetPreferences(context.Context, UID, any) error
+	SetPreferences(context.Context, UID, any) error
+
+	// GetUser gets the user data saved by the user service
+	GetUser(context.Context, UID, any) error
+
+	GetRating(context.Context, string, any) error
+	// GetLegacyElo gets the Elo rating of a user saved before ratings were kept per rating system, game type, and player count
+	GetLegacyElo(context.Context, UID, any) error
+	ListRatings(context.Context, RatingQuery) ([]Doc, error)
+	ListRatingHistory(context.Context, UID, RatingQuery) ([]Doc, error)
+	GetUStat(context.Context, UID, any) error
+
+	GetSubs(context.Context, string, UID, any) error
+	SetSubs(context.Context, string, UID, any) error
+	DeleteSubs(context.Context, string, UID) error
+	SetInvitationSubs(context.Context, string, UID, any) error
+
+	AddMessage(context.Context, string, any) (string, error)
+	MarkRead(context.Context, string, string, UID) error
+	// ListMessages lists the messages of the game in order of creation
+	ListMessages(context.Context, string) ([]Doc, error)
+
+	// Whispers are private messages, stored apart from messages, and readable only by the users of their Audience
+	AddWhisper(context.Context, string, any) (string, error)
+	// ListWhispers lists the whispers of the game having the user in their Audience, in order of creation
+	ListWhispers(context.Context, string, UID) ([]Doc, error)
+	GetChatModeration(context.Context, string, any) error
+
+	// Idempotency records provide the first response to requests of a game having an Idempotency-Key
+	SetIdempotencyRecord(context.Context, string, string, any) error
+	DeleteIdempotencyRecord(context.Context, string, string) error
+}
+
+// Tx provides the transactional operations used by a GameClient.
+// As with Firestore, all reads of a transaction must precede its writes.
+type Tx interface {
+	GetIndex(string, any) error
+	GetStack(string, UID, *Stack) error
+
+	SetRev(string, Rev, any) error
+	SetCached(string, UID, Rev, any) error
+	DeleteCached(string, UID, Rev) error
+	SetStack(string, UID, *Stack) error
+	SetView(string, UID, any) error
+	SetIndex(string, any) error
+
+	GetInvitation(string, any) error
+	CreateInvitation(any) (string, error)
+	SetInvitation(string, any) error
+	DeleteInvitation(string) error
+	CreateHash(string, []byte) error
+	SetHash(string, []byte) error
+	DeleteHash(string) error
+
+	GetTournament(string, any) error
+	CreateTournament(any) (string, error)
+	SetTournament(string, any) error
+
+	GetQueueEntry(string, any) error
+	SetQueueEntry(string, any) error
+	DeleteQueueEntry(string) error
+
+	GetOutboxEntry(string, any) error
+	SetOutboxEntry(string, any) error
+	DeleteOutboxEntry(string) error
+
+	GetDigest(UID, any) error
+	SetDigest(UID, any) error
+	DeleteDigest(UID) error
+
+	GetMessage(string, string, any) error
+	SetMessage(string, string, any) error
+	GetWhisper(string, string, any) error
+	SetWhisper(string, string, any) error
+	GetChatModeration(string, any) error
+	SetChatModeration(string, any) error
+
+	SetRating(string, any) error
+	AddEloHistory(UID, any) error
+	SetUStat(UID, any) error
+
+	GetIdempotencyRecord(string, string, any) error
+	SetIdempotencyRecord(string, string, any) error
+}
+
+// Doc provides a document returned by a Store query
+type Doc interface {
+	// ID returns the id of the document
+	ID() string
+	// DataTo decodes the document into dst
+	DataTo(dst any) error
+}
+
+// IndexQuery provides criteria for listing indexed games.
+// Zero valued criteria are ignored.
+type IndexQuery struct {
+	// Status limits results to games having the status
+	Status Status
+	// CheckBy limits results to games having a TurnCheckAt at or before CheckBy
+	CheckBy time.Time
+	// Type limits results to games of the type
+	Type Type
+	// UserID limits results to games having the user as a player.
+	// Ignored if CurrentUserID is set, as Firestore permits a single array-contains filter per query.
+	UserID UID
+	// CurrentUserID limits results to games having the user as a current player
+	CurrentUserID UID
+	// SortByUpdatedAt orders results by UpdatedAt, and then by id
+	SortByUpdatedAt bool
+	// Desc orders results in descending order
+	Desc bool
+	// After, if not nil, limits results to those following the cursor in the order of results
+	After *IndexCursor
+	// Limit provides the maximum number of results
+	Limit int
+}
+
+// IndexCursor provides the position of a game within indexed games ordered by UpdatedAt
+type IndexCursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// InvitationQuery provides criteria for listing invitations.
+// Zero valued criteria are ignored.
+type InvitationQuery struct {
+	// Status limits results to invitations having the status
+	Status Status
+	// Type limits results to invitations of the type
+	Type Type
+	// NumPlayers limits results to invitations for the player count
+	NumPlayers int
+	// Private, if not nil, limits results to private or public invitations
+	Private *bool
+	// CreatorID limits results to invitations created by the user
+	CreatorID UID
+	// UserID limits results to invitations joined by the user
+	UserID UID
+	// Offset provides the number of results to skip
+	Offset int
+	// Limit provides the maximum number of results
+	Limit int
+}
+
+// RatingQuery provides criteria for listing ratings.
+// Zero valued criteria are ignored.
+type RatingQuery struct {
+	// System limits results to ratings of the rating system
+	System string
+	// Type limits results to ratings for the game type
+	Type Type
+	// NumPlayers limits results to ratings for the player count
+	NumPlayers int
+	// OrderBy provides the Rating field by which results are ordered
+	OrderBy string
+	// Desc orders results in descending order
+	Desc bool
+	// Offset provides the number of results to skip
+	Offset int
+	// Limit provides the maximum number of results
+	Limit int
+}
+
+// WithStore sets the store used by a GameClient.
+// If not set, NewGameClient connects to Firestore and uses it as the store.
+func WithStore(s Store) Option {
+	return func(cl *Client) *Client {
+		cl.store = s
+		return cl
+	}
+}
+
+// newDocID returns a random document id in the style of Firestore auto-generated ids
+func newDocID() string {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	const length = 20
+	id := make([]byte, length)
+	for i := range id {
+		id[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(id)
+}
@@ -0,0 +1,163 @@
+package sn
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamKeepAlive provides the interval at which comments are sent to keep idle streams open
+const streamKeepAlive = 30 * time.Second
+
+// streamHandler pushes updates of a game to the current user as Server-Sent Events.
+// A view event, providing the view of the game for the current user, is sent upon connecting,
+// and whenever a revision is committed or the current user changes their cached revisions.
+// A message event is sent whenever a message is added to the message log of the game,
+// unless the current user is banned from the message log.
+// Users that are neither players nor admins receive delayed spectator views, provided the game permits spectators.
+//
+// Events are provided by the in-process broker of the client, and thus only changes made via
+// the same instance of the service are pushed.
+func (cl *GameClient[GT, G]) streamHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		gid := getID(ctx)
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if _, _, err := cl.viewerFor(cu, index); err != nil {
+			JErr(ctx, err)
+			return
+		}
+		spectator := index.spectator(cu)
+
+		// subscribe prior to loading the initial view, so no change is missed
+		events, unsubscribe := cl.broker.subscribe(gid)
+		defer unsubscribe()
+
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Status(http.StatusOK)
+
+		if err := cl.streamView(ctx, gid, cu, spectator); err != nil {
+			Warnf(ctx, "unable to stream view of %s: %v", gid, err)
+			return
+		}
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-keepAlive.C:
+				if _, err := ctx.Writer.WriteString(": keep-alive\n\n"); err != nil {
+					return
+				}
+				ctx.Writer.Flush()
+			case e := <-events:
+				if err := cl.streamEvent(ctx, e, cu, spectator); err != nil {
+					Warnf(ctx, "unable to stream %s event of %s: %v", e.Kind, gid, err)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (cl *GameClient[GT, G]) streamEvent(ctx *gin.Context, e gameEvent, cu *User, spectator bool) error {
+	switch e.Kind {
+	case MessageEvent, MessageUpdatedEvent:
+		// whispers are only streamed to their audience
+		if !e.Message.visibleTo(cu.ID) {
+			return nil
+		}
+
+		// bans are checked per message, so users banned while streaming receive no further messages
+		if ok, err := cl.canStreamMessages(ctx, e.GID, cu); err != nil || !ok {
+			return err
+		}
+		ctx.SSEvent(string(e.Kind), gin.H{"ID": e.MessageID, "Message": e.Message})
+		ctx.Writer.Flush()
+		return nil
+	case CacheEvent, StackEvent:
+		// cached revisions are only visible to the user that cached them
+		if spectator || e.UID != cu.ID {
+			return nil
+		}
+	}
+	return cl.streamView(ctx, e.GID, cu, spectator)
+}
+
+// canStreamMessages returns true unless the user is banned from the message log of the game.
+// The user may otherwise view the message log, as streamHandler permits only players, admins, and permitted spectators.
+func (cl *GameClient[GT, G]) canStreamMessages(ctx *gin.Context, gid string, cu *User) (bool, error) {
+	if cu.Admin {
+		return true, nil
+	}
+
+	mod, err := cl.getChatModeration(ctx, gid)
+	if err != nil {
+		return false, err
+	}
+	return mod.canView(cu.ID, time.Now()) == nil, nil
+}
+
+// streamView sends a view event providing the current view of the game for the user
+func (cl *GameClient[GT, G]) streamView(ctx *gin.Context, gid string, cu *User, spectator bool) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	var (
+		view *GT
+		rev  Rev
+	)
+
+	if spectator {
+		index, err := cl.getIndex(ctx, gid)
+		if err != nil {
+			return err
+		}
+
+		rev = index.spectatorRev(index.Rev)
+		g, err := cl.getCommittedRev(ctx, gid, rev)
+		if err != nil {
+			return err
+		}
+		view = cl.spectatorView(g)
+	} else {
+		// admins that are not players have no stack of their own, and thus view the committed game
+		stack, err := cl.getStack(ctx, gid, cu.ID)
+		if errors.Is(err, ErrNotFound) {
+			stack, err = cl.getStack(ctx, gid, 0)
+		}
+		if err != nil {
+			return err
+		}
+
+		g, err := cl.getGameWithStack(ctx, gid, cu.ID, stack)
+		if err != nil {
+			return err
+		}
+		rev, view = stack.Current, cl.viewFor(g, cu.ID)
+	}
+
+	ctx.SSEvent("view", gin.H{"Rev": rev, "Game": view})
+	ctx.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,64 @@
+package sn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamViewOfAdmin(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	admin := &User{ID: 9, userData: userData{Name: "admin", Admin: true}}
+	if i := getTestIndex(t, cl); i.spectator(admin) || !i.spectator(testUser(9)) || i.spectator(testUser(1)) {
+		t.Fatal("admins and players must not view the game as spectators, while other users must")
+	}
+
+	// admins that are not players stream the committed game, rather than the delayed spectator view
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := cl.streamView(ctx, testGID, admin, false); err != nil {
+		t.Fatalf("streamView: %v", err)
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, "event:view") {
+		t.Errorf("body = %s, want a view event", body)
+	}
+}
+
+func TestStreamEventWithholdsMessagesFromBanned(t *testing.T) {
+	cl := newTestClient(t)
+	startTestGame(t, cl, 1, 2)
+
+	if err := cl.store.RunTransaction(context.Background(), func(_ context.Context, tx Tx) error {
+		return tx.SetChatModeration(testGID, chatModeration{Banned: []chatRestriction{{UID: 2}}})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := gameEvent{Kind: MessageEvent, GID: testGID, MessageID: "m1", Message: &Message{Text: "hello"}}
+	for _, tc := range []struct {
+		uid  UID
+		want bool
+	}{
+		{uid: 1, want: true},
+		{uid: 2},
+	} {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := cl.streamEvent(ctx, e, testUser(tc.uid), false); err != nil {
+			t.Fatalf("user %d: streamEvent: %v", tc.uid, err)
+		}
+
+		if got := strings.Contains(w.Body.String(), "hello"); got != tc.want {
+			t.Errorf("user %d: streamed message = %v, want %v", tc.uid, got, tc.want)
+		}
+	}
+}
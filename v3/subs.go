@@ -2,15 +2,13 @@ package sn
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/elliotchance/pie/v2"
 	"github.com/gin-gonic/gin"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // SubToken represents a firebase subscription string used for sending WebPush notifications
@@ -30,22 +28,6 @@ func (subs *subscriptions) toTokenStrings() []string {
 	return pie.Map(subs.Subs, func(sub subscription) string { return string(sub.Token) })
 }
 
-func (cl *GameClient[GT, G]) subCollectionRef(gid string) *firestore.CollectionRef {
-	return cl.gameDocRef(gid).Collection("Sub")
-}
-
-func (cl *GameClient[GT, G]) subDocRef(gid string, uid UID) *firestore.DocumentRef {
-	return cl.subCollectionRef(gid).Doc(uid.toString())
-}
-
-func (cl *GameClient[GT, G]) subInvCollectionRef(id string) *firestore.CollectionRef {
-	return cl.invitationDocRef(id).Collection("Sub")
-}
-
-func (cl *GameClient[GT, G]) subInvDocRef(id string, uid UID) *firestore.DocumentRef {
-	return cl.subInvCollectionRef(id).Doc(uid.toString())
-}
-
 func (cl *GameClient[GT, G]) addSub(ctx *gin.Context, gid string, token SubToken, uid UID) error {
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
@@ -59,8 +41,7 @@ func (cl *GameClient[GT, G]) addSub(ctx *gin.Context, gid string, token SubToken
 		Subs: []subscription{{Token: token, Time: t}},
 		Time: t,
 	}
-	_, err := cl.subInvDocRef(gid, uid).Set(ctx, newSubs)
-	return err
+	return cl.store.SetInvitationSubs(ctx, gid, uid, newSubs)
 }
 
 func (cl *GameClient[GT, G]) removeSubs(ctx *gin.Context, gid string, uid UID) error {
@@ -69,8 +50,7 @@ func (cl *GameClient[GT, G]) removeSubs(ctx *gin.Context, gid string, uid UID) e
 
 	k := subscriptionKey(gid, uid)
 	cl.Cache.Delete(k)
-	_, err := cl.subDocRef(gid, uid).Delete(ctx)
-	return err
+	return cl.store.DeleteSubs(ctx, gid, uid)
 }
 
 func (cl *GameClient[GT, G]) updateSubs(ctx *gin.Context, gid string, token SubToken, uid UID) error {
@@ -82,7 +62,7 @@ func (cl *GameClient[GT, G]) updateSubs(ctx *gin.Context, gid string, token SubT
 	}
 
 	subs, err := cl.getSubscriptions(ctx, gid, uid)
-	if (err != nil) && (status.Code(err) != codes.NotFound) {
+	if (err != nil) && !errors.Is(err, ErrNotFound) {
 		return err
 	}
 
@@ -117,7 +97,7 @@ func (cl *GameClient[GT, G]) updateSubs(ctx *gin.Context, gid string, token SubT
 		return nil
 	}
 
-	if _, err := cl.subDocRef(gid, uid).Set(ctx, newSubs); err != nil {
+	if err := cl.store.SetSubs(ctx, gid, uid, newSubs); err != nil {
 		return err
 	}
 
@@ -151,14 +131,9 @@ func (cl *GameClient[GT, G]) dsGetSubscriptions(ctx context.Context, gid string,
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	snap, err := cl.subDocRef(gid, uid).Get(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get subscriptions for: %v: %w", uid, err)
-	}
-
 	subs := new(subscriptions)
-	if err := snap.DataTo(subs); err != nil {
-		return nil, fmt.Errorf("unable to get data from snapshot: %w", err)
+	if err := cl.store.GetSubs(ctx, gid, uid, subs); err != nil {
+		return nil, fmt.Errorf("unable to get subscriptions for: %v: %w", uid, err)
 	}
 
 	k := subscriptionKey(gid, uid)
@@ -186,7 +161,7 @@ func (cl *GameClient[GT, G]) getTokenStrings(ctx context.Context, gid string, ui
 	var tokens []string
 	for _, uid := range uids {
 		subs, err := cl.getSubscriptions(ctx, gid, uid)
-		if status.Code(err) == codes.NotFound {
+		if errors.Is(err, ErrNotFound) {
 			continue
 		}
 		if err != nil {
@@ -201,8 +176,7 @@ func (cl *GameClient[GT, G]) putSubscriptions(ctx context.Context, gid string, u
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	_, err := cl.subDocRef(gid, uid).Set(ctx, subs)
-	if err != nil {
+	if err := cl.store.SetSubs(ctx, gid, uid, subs); err != nil {
 		return err
 	}
 
@@ -211,6 +185,23 @@ func (cl *GameClient[GT, G]) putSubscriptions(ctx context.Context, gid string, u
 	return nil
 }
 
+// pruneTokens removes the tokens from the subscriptions of the user to the game
+func (cl *GameClient[GT, G]) pruneTokens(ctx context.Context, gid string, uid UID, tokens []string) error {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	subs, err := cl.getSubscriptions(ctx, gid, uid)
+	if err != nil {
+		return err
+	}
+
+	kept := pie.Filter(subs.Subs, func(sub subscription) bool { return !slices.Contains(tokens, string(sub.Token)) })
+	if len(kept) == len(subs.Subs) {
+		return nil
+	}
+	return cl.putSubscriptions(ctx, gid, uid, &subscriptions{Subs: kept, Time: subs.Time})
+}
+
 func subscriptionKey(gid string, uid UID) string {
 	return fmt.Sprintf("subkey-%s-%d", gid, uid)
 }
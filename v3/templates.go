@@ -0,0 +1,206 @@
+package sn
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// defaultTemplates provides the notification templates shipped with the package.
+//
+// Templates are laid out as <locale>/<name>.tmpl, with optional per game type overrides at <locale>/<type>/<name>.tmpl.
+// Each .tmpl file is a text/template defining the "title", "subject", and "text" templates,
+// where "title" is used for push notifications and "subject" and "text" for email.
+// An optional html/template, <name>.html.tmpl, alongside the .tmpl file provides the html part of email.
+//
+//go:embed templates
+var defaultTemplates embed.FS
+
+// defaultLocale provides the locale used when no template exists for the locale of a user
+const defaultLocale = "en"
+
+var templateFuncs = map[string]any{
+	"sentence": func(v any) string { return toSentence(toStrings(v)) },
+	"join":     func(v any, sep string) string { return strings.Join(toStrings(v), sep) },
+}
+
+// toStrings converts the elements of a slice to strings.
+// Needed as slices of data stored in the outbox are returned as []any.
+func toStrings(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		ss := make([]string, len(v))
+		for i, s := range v {
+			ss[i] = fmt.Sprint(s)
+		}
+		return ss
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// content provides rendered notification content
+type content struct {
+	Title   string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type parsedTemplate struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// templateRegistry provides notification templates by locale, game type, and name.
+// Templates are looked up in the file systems in order, so templates added via WithTemplates override the defaults.
+type templateRegistry struct {
+	mu    sync.Mutex
+	fss   []fs.FS
+	cache map[string]*parsedTemplate
+}
+
+func newTemplateRegistry() *templateRegistry {
+	sub, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	return &templateRegistry{fss: []fs.FS{sub}, cache: make(map[string]*parsedTemplate)}
+}
+
+// WithTemplates adds notification templates overriding the default templates.
+// The file system must follow the layout of the default templates, e.g., de/your-turn.tmpl or de/tammany/your-turn.tmpl.
+func WithTemplates(fsys fs.FS) Option {
+	return func(cl *Client) *Client {
+		cl.templates.mu.Lock()
+		defer cl.templates.mu.Unlock()
+
+		cl.templates.fss = append([]fs.FS{fsys}, cl.templates.fss...)
+		clear(cl.templates.cache)
+		return cl
+	}
+}
+
+// render renders the named template for the locale and game type.
+// Falls back from the locale to its base language (e.g., pt-BR to pt) and then to the default locale,
+// and, within each locale, from the game type specific template to the generic template.
+func (r *templateRegistry) render(locale string, t Type, name string, data H) (content, error) {
+	for _, loc := range localeChain(locale) {
+		dirs := []string{loc}
+		if t != NoType {
+			dirs = []string{path.Join(loc, string(t)), loc}
+		}
+
+		for _, dir := range dirs {
+			pt, err := r.lookup(path.Join(dir, name))
+			if err != nil {
+				return content{}, err
+			}
+			if pt != nil {
+				return pt.execute(data)
+			}
+		}
+	}
+	return content{}, fmt.Errorf("no template %q for locale %q: %w", name, locale, ErrNotFound)
+}
+
+// lookup returns the parsed template for the path, without extension.
+// Returns nil if no file system has the template.
+func (r *templateRegistry) lookup(p string) (*parsedTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pt, ok := r.cache[p]; ok {
+		return pt, nil
+	}
+
+	text, err := r.readFile(p + ".tmpl")
+	if errors.Is(err, fs.ErrNotExist) {
+		r.cache[p] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pt := new(parsedTemplate)
+	if pt.text, err = template.New(p).Funcs(templateFuncs).Parse(string(text)); err != nil {
+		return nil, err
+	}
+
+	html, err := r.readFile(p + ".html.tmpl")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if pt.html, err = htmltemplate.New(p).Funcs(templateFuncs).Parse(string(html)); err != nil {
+			return nil, err
+		}
+	}
+
+	r.cache[p] = pt
+	return pt, nil
+}
+
+func (r *templateRegistry) readFile(name string) ([]byte, error) {
+	for _, fsys := range r.fss {
+		b, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return b, err
+	}
+	return nil, fs.ErrNotExist
+}
+
+func (pt *parsedTemplate) execute(data H) (content, error) {
+	var c content
+	for _, part := range []struct {
+		name string
+		s    *string
+	}{{"title", &c.Title}, {"subject", &c.Subject}, {"text", &c.Text}} {
+		if pt.text.Lookup(part.name) == nil {
+			continue
+		}
+
+		buf := new(bytes.Buffer)
+		if err := pt.text.ExecuteTemplate(buf, part.name, data); err != nil {
+			return content{}, err
+		}
+		*part.s = strings.TrimSpace(buf.String())
+	}
+
+	if pt.html != nil {
+		buf := new(bytes.Buffer)
+		if err := pt.html.Execute(buf, data); err != nil {
+			return content{}, err
+		}
+		c.HTML = buf.String()
+	}
+	return c, nil
+}
+
+// localeChain returns the locales in which templates are looked up for the locale
+func localeChain(locale string) []string {
+	var locs []string
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if locale != "" {
+		locs = append(locs, locale)
+		if base, _, found := strings.Cut(locale, "-"); found {
+			locs = append(locs, base)
+		}
+	}
+	return append(locs, defaultLocale)
+}
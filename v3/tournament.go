@@ -0,0 +1,551 @@
+package sn
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TournamentFormat represents the format of a tournament
+type TournamentFormat string
+
+const (
+	// Swiss specifies a tournament in which entrants of similar standing are seated together each round
+	Swiss TournamentFormat = "swiss"
+
+	// RoundRobin specifies a tournament in which entrants are seated with differing opponents each round
+	RoundRobin TournamentFormat = "round-robin"
+
+	// SingleElimination specifies a tournament in which only the winner of each table advances to the next round
+	SingleElimination TournamentFormat = "single-elimination"
+)
+
+// maxTournamentEntrants provides the largest number of entrants of a tournament
+const maxTournamentEntrants = 128
+
+// tournament provides a tournament of games of a game type.
+// The embedded Header provides the settings of the games of the tournament, and the users of the Header
+// provide the entrants of the tournament. NumPlayers provides the number of players seated at each table.
+type tournament struct {
+	Header
+	Format      TournamentFormat
+	MaxEntrants int
+	Rounds      int
+	Round       int
+	// Seeds provides the entrants in seed order, as determined by rating when the tournament starts
+	Seeds     []UID
+	Tables    []table
+	Byes      []bye
+	Standings []standing
+}
+
+func (cl *GameClient[GT, G]) createTournamentHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		t, err := tournamentFromForm(ctx, cu)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			now := timestamppb.Now()
+			t.CreatedAt, t.UpdatedAt = now, now
+			id, err := tx.CreateTournament(t)
+			if err != nil {
+				return err
+			}
+			t.setID(id)
+			return nil
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Tournament": t.public(),
+			"Message":    fmt.Sprintf("%s created tournament %q", cu.Name, t.Title),
+		})
+	}
+}
+
+func tournamentFromForm(ctx *gin.Context, cu *User) (tournament, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	obj := struct {
+		Type            Type
+		Title           string
+		NumPlayers      int
+		OptString       string
+		Format          TournamentFormat
+		Rounds          int
+		MaxEntrants     int
+		TurnLimitHours  int
+		TimeoutAction   TimeoutAction
+		AllowSpectators bool
+		SpectatorDelay  int
+	}{}
+
+	if err := ctx.ShouldBind(&obj); err != nil {
+		return tournament{}, err
+	}
+
+	var t tournament
+	t.Title = cu.Name + "'s Tournament"
+	if obj.Title != "" {
+		t.Title = obj.Title
+	}
+
+	t.Type = obj.Type
+	t.NumPlayers = obj.NumPlayers
+	t.OptString = obj.OptString
+	t.Format = cmp.Or(obj.Format, Swiss)
+	t.Rounds = obj.Rounds
+	t.MaxEntrants = cmp.Or(obj.MaxEntrants, maxTournamentEntrants)
+	t.Status = Recruiting
+	t.Seed = NewSeed()
+
+	switch {
+	case t.Format != Swiss && t.Format != RoundRobin && t.Format != SingleElimination:
+		return tournament{}, fmt.Errorf("unknown tournament format %q: %w", t.Format, ErrValidation)
+	case t.NumPlayers < 2:
+		return tournament{}, fmt.Errorf("tables must seat at least 2 players: %w", ErrValidation)
+	case t.MaxEntrants < t.NumPlayers || t.MaxEntrants > maxTournamentEntrants:
+		return tournament{}, fmt.Errorf("maximum entrants must be between %d and %d: %w",
+			t.NumPlayers, maxTournamentEntrants, ErrValidation)
+	case t.Rounds < 0:
+		return tournament{}, fmt.Errorf("rounds must not be negative: %w", ErrValidation)
+	}
+
+	if err := t.setTurnLimit(obj.TurnLimitHours, obj.TimeoutAction); err != nil {
+		return tournament{}, err
+	}
+
+	if err := t.setSpectators(obj.AllowSpectators, obj.SpectatorDelay); err != nil {
+		return tournament{}, err
+	}
+
+	t.addCreator(cu)
+	t.addUser(cu)
+	return t, nil
+}
+
+func (cl *GameClient[GT, G]) joinTournamentHandler() gin.HandlerFunc {
+	return cl.entrantHandler(func(t *tournament, cu *User) (string, error) {
+		switch {
+		case t.Status != Recruiting:
+			return "", fmt.Errorf("tournament is no longer recruiting: %w", ErrValidation)
+		case t.hasUser(cu):
+			return "", fmt.Errorf("%s has already joined tournament: %w", cu.Name, ErrValidation)
+		case len(t.UserIDS) >= t.MaxEntrants:
+			return "", fmt.Errorf("tournament already has the maximum number of entrants: %w", ErrValidation)
+		}
+
+		t.addUser(cu)
+		return fmt.Sprintf("%s joined tournament: %s", cu.Name, t.Title), nil
+	})
+}
+
+func (cl *GameClient[GT, G]) leaveTournamentHandler() gin.HandlerFunc {
+	return cl.entrantHandler(func(t *tournament, cu *User) (string, error) {
+		switch {
+		case t.Status != Recruiting:
+			return "", fmt.Errorf("tournament is no longer recruiting, thus %s can't leave: %w", cu.Name, ErrValidation)
+		case !t.hasUser(cu):
+			return "", fmt.Errorf("%s has not joined tournament: %w", cu.Name, ErrValidation)
+		}
+
+		t.removeUser(cu)
+		return fmt.Sprintf("%s left tournament: %s", cu.Name, t.Title), nil
+	})
+}
+
+// entrantHandler transactionally updates the entrants of a tournament for the current user per update
+func (cl *GameClient[GT, G]) entrantHandler(update func(*tournament, *User) (string, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var (
+			t   *tournament
+			msg string
+		)
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			var err error
+			if t, err = cl.txGetTournament(tx, getID(ctx)); err != nil {
+				return err
+			}
+
+			if msg, err = update(t, cu); err != nil {
+				return err
+			}
+
+			t.UpdatedAt = timestamppb.Now()
+			return tx.SetTournament(t.id(), t)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Tournament": t.public(), "Message": msg})
+	}
+}
+
+// startTournamentHandler seeds the entrants by rating and starts the games of the first round.
+// The tournament may be started by its creator or an admin.
+func (cl *GameClient[GT, G]) startTournamentHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		cu, err := cl.RequireLogin(ctx)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		t, err := cl.getTournament(ctx, getID(ctx))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := t.validateStart(cu); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		seeds, err := cl.seedsFor(ctx, t)
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if err := cl.validateFinalTable(t, len(seeds)); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		var started []G
+		if err := cl.store.RunTransaction(ctx, func(_ context.Context, tx Tx) error {
+			t, err := cl.txGetTournament(tx, t.id())
+			if err != nil {
+				return err
+			}
+
+			if err := t.validateStart(cu); err != nil {
+				return err
+			}
+
+			if !slices.Equal(slices.Sorted(slices.Values(t.UserIDS)), slices.Sorted(slices.Values(seeds))) {
+				return fmt.Errorf("entrants of tournament changed, please try again: %w", ErrValidation)
+			}
+
+			now := timestamppb.Now()
+			t.Seeds = seeds
+			t.Status = Running
+			t.StartedAt, t.UpdatedAt = now, now
+			t.Rounds = t.roundsFor(len(seeds))
+
+			if started, err = cl.txStartRound(ctx, tx, t); err != nil {
+				return err
+			}
+			return tx.SetTournament(t.id(), t)
+		}); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		if len(started) > 0 {
+			cl.kickOutbox()
+		}
+		ctx.JSON(http.StatusOK, gin.H{"Message": fmt.Sprintf("Tournament: %s has started.", t.Title)})
+	}
+}
+
+func (t *tournament) validateStart(cu *User) error {
+	switch {
+	case cu.ID != t.CreatorID && !cu.Admin:
+		return fmt.Errorf("only the creator may start tournament: %w", ErrValidation)
+	case t.Status != Recruiting:
+		return fmt.Errorf("tournament has already started: %w", ErrValidation)
+	case len(t.UserIDS) < t.NumPlayers:
+		return fmt.Errorf("tournament requires at least %d entrants: %w", t.NumPlayers, ErrValidation)
+	default:
+		return nil
+	}
+}
+
+// validateFinalTable returns an error if the final table of a single-elimination tournament having numEntrants entrants
+// would seat fewer than NumPlayers players, unless the game implements PlayerRanger and supports the player count.
+// Earlier rounds provide byes, such that each table seats NumPlayers players.
+func (cl *GameClient[GT, G]) validateFinalTable(t *tournament, numEntrants int) error {
+	if t.Format != SingleElimination {
+		return nil
+	}
+
+	n := finalTableSize(numEntrants, t.NumPlayers)
+	if n == t.NumPlayers {
+		return nil
+	}
+
+	if pr, ok := any(G(new(GT))).(PlayerRanger); ok {
+		if lo, hi := pr.PlayerRange(); n >= lo && n <= hi {
+			return nil
+		}
+	}
+	return fmt.Errorf("with %d entrants, the final table would seat %d players, but tables must seat %d players: %w",
+		numEntrants, n, t.NumPlayers, ErrValidation)
+}
+
+// roundsFor returns the number of rounds of the tournament having numEntrants entrants.
+// Swiss and round-robin tournaments may be configured with fewer rounds.
+func (t *tournament) roundsFor(numEntrants int) int {
+	rounds := roundsFor(t.Format, numEntrants, t.NumPlayers)
+	if t.Format == SingleElimination || t.Rounds == 0 {
+		return rounds
+	}
+	if t.Format == RoundRobin {
+		return min(t.Rounds, rounds)
+	}
+	return t.Rounds
+}
+
+// seedsFor returns the entrants of the tournament in seed order.
+// Entrants are seeded by rating, with ties broken randomly.
+func (cl *GameClient[GT, G]) seedsFor(ctx *gin.Context, t *tournament) ([]UID, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	ratings, err := cl.getRatings(ctx, t.Type, t.NumPlayers, t.users()...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newRand(&t.Header, 0)
+	r.Shuffle(len(ratings), func(i, j int) { ratings[i], ratings[j] = ratings[j], ratings[i] })
+	slices.SortStableFunc(ratings, func(r1, r2 Rating) int { return cmp.Compare(r2.Rating, r1.Rating) })
+
+	seeds := make([]UID, len(ratings))
+	for i, r := range ratings {
+		seeds[i] = r.UID
+	}
+	return seeds, nil
+}
+
+// txStartRound advances the tournament to its next round and starts the games of the round.
+// If no rounds remain, the tournament is completed.
+func (cl *GameClient[GT, G]) txStartRound(ctx context.Context, tx Tx, t *tournament) ([]G, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	t.updateStandings()
+	t.Round++
+	if t.Round > t.Rounds {
+		t.Round = t.Rounds
+		t.Status = Completed
+		t.EndedAt = timestamppb.Now()
+		return nil, nil
+	}
+
+	tables, byes := t.seat(t.Round)
+	t.Byes = append(t.Byes, byes...)
+
+	users := make(map[UID]*User, len(t.UserIDS))
+	for _, u := range t.users() {
+		users[u.ID] = u
+	}
+
+	gs := make([]G, len(tables))
+	for i := range tables {
+		tables[i].GID = fmt.Sprintf("%s-%d-%d", t.id(), tables[i].Round, tables[i].Table)
+		g, _, err := cl.startGame(ctx, t.tableHeader(tables[i], users))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cl.txSaveStarted(ctx, tx, g, tables[i].UserIDS[0],
+			startedNotification(g), turnNotification(g, g.header().CPIDS)); err != nil {
+			return nil, err
+		}
+		tables[i].Status = Running
+		gs[i] = g
+	}
+
+	t.Tables = append(t.Tables, tables...)
+	t.updateStandings()
+
+	// a round consisting solely of byes is complete upon starting
+	if len(tables) == 0 {
+		return cl.txStartRound(ctx, tx, t)
+	}
+	return gs, nil
+}
+
+// tableHeader returns the header used to start the game of the table
+func (t *tournament) tableHeader(tb table, users map[UID]*User) Header {
+	var h Header
+	h.setID(tb.GID)
+	h.Type = t.Type
+	h.Title = fmt.Sprintf("%s: Round %d, Table %d", t.Title, tb.Round, tb.Table)
+	h.NumPlayers = len(tb.UserIDS)
+	h.OptString = t.OptString
+	h.CreatorID = t.CreatorID
+	h.CreatorName = t.CreatorName
+	h.CreatorEmail = t.CreatorEmail
+	h.CreatorEmailNotifications = t.CreatorEmailNotifications
+	h.CreatorEmailHash = t.CreatorEmailHash
+	h.CreatorGravType = t.CreatorGravType
+	h.TurnLimit, h.TimeoutAction = t.TurnLimit, t.TimeoutAction
+	h.AllowSpectators, h.SpectatorDelay = t.AllowSpectators, t.SpectatorDelay
+	h.TournamentID = t.id()
+	h.Status = Recruiting
+	now := timestamppb.Now()
+	h.CreatedAt, h.UpdatedAt = now, now
+
+	for _, uid := range tb.UserIDS {
+		h.addUser(users[uid])
+	}
+	return h
+}
+
+// txGetTournamentOf returns the tournament of the game of header h, or nil if the game is not a tournament game.
+// Permits recording the game in the transaction ending the game, as reads must precede the writes of a transaction.
+func (cl *GameClient[GT, G]) txGetTournamentOf(tx Tx, h *Header) (*tournament, error) {
+	if h.TournamentID == "" {
+		return nil, nil
+	}
+	return cl.txGetTournament(tx, h.TournamentID)
+}
+
+// txRecordTournamentGame records the result of the ended game of header h into the standings of tournament t, if any.
+// Once all games of the current round have ended, the next round is started, and its games returned.
+func (cl *GameClient[GT, G]) txRecordTournamentGame(ctx context.Context, tx Tx, h *Header, t *tournament) ([]G, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	if t == nil || !t.record(h) {
+		return nil, nil
+	}
+
+	var (
+		started []G
+		err     error
+	)
+	if t.roundComplete() {
+		if started, err = cl.txStartRound(ctx, tx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	t.UpdatedAt = timestamppb.Now()
+	return started, tx.SetTournament(t.id(), t)
+}
+
+// record records the places of the ended game of header h into the table of the game.
+// Players of an abandoned game dropped for exceeding the turn limit are placed last, and the other players tie for first.
+// Returns false if the game is not a running game of the tournament.
+func (t *tournament) record(h *Header) bool {
+	i := slices.IndexFunc(t.Tables, func(tb table) bool { return tb.GID == h.id() && tb.Places == nil })
+	if i == -1 {
+		return false
+	}
+
+	tb := &t.Tables[i]
+	last := len(tb.UserIDS)
+	tb.Places = make([]int, last)
+	for j, uid := range tb.UserIDS {
+		place := h.Places[uid.toString()]
+		switch {
+		case h.Status != Completed && slices.Contains(h.DroppedIDS, uid):
+			place = last
+		case h.Status != Completed:
+			place = 1
+		case place == 0:
+			place = last
+		}
+		tb.Places[j] = place
+	}
+	tb.Status = h.Status
+
+	t.updateStandings()
+	return true
+}
+
+// roundComplete returns true if all games of the current round have ended
+func (t *tournament) roundComplete() bool {
+	return !slices.ContainsFunc(t.Tables, func(tb table) bool { return tb.Round == t.Round && tb.Places == nil })
+}
+
+func (cl *GameClient[GT, G]) showTournamentHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		Debugf(ctx, msgEnter)
+		defer Debugf(ctx, msgExit)
+
+		if _, err := cl.RequireLogin(ctx); err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		t, err := cl.getTournament(ctx, getID(ctx))
+		if err != nil {
+			JErr(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"Tournament": t.public()})
+	}
+}
+
+// public returns a copy of the tournament with the email addresses of users and the seed removed,
+// as the seed determines the seating of the tournament.
+func (t *tournament) public() *tournament {
+	t2 := DeepCopy(t)
+	t2.Seed = 0
+	t2.CreatorEmail = ""
+	t2.UserEmails = make([]string, len(t2.UserEmails))
+	return t2
+}
+
+func (cl *GameClient[GT, G]) getTournament(ctx context.Context, id string) (*tournament, error) {
+	Debugf(ctx, msgEnter)
+	defer Debugf(ctx, msgExit)
+
+	t := new(tournament)
+	if err := cl.store.GetTournament(ctx, id, t); err != nil {
+		return nil, err
+	}
+
+	t.setID(id)
+	return t, nil
+}
+
+func (cl *GameClient[GT, G]) txGetTournament(tx Tx, id string) (*tournament, error) {
+	t := new(tournament)
+	if err := tx.GetTournament(id, t); err != nil {
+		return nil, err
+	}
+
+	t.setID(id)
+	return t, nil
+}
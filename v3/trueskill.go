@@ -0,0 +1,171 @@
+package sn
+
+import (
+	"math"
+	"slices"
+)
+
+// TrueSkillRater provides a multiplayer TrueSkill-style rating system.
+// Players are ordered by place, and each pair of adjacently placed players is updated
+// as a two player TrueSkill match. Updates are computed from the ratings prior to the game,
+// and thus do not depend upon the order in which pairs are considered.
+type TrueSkillRater struct {
+	// DrawProbability provides the probability of a draw between equally skilled players.
+	// If zero, defaults to 0.1
+	DrawProbability float64
+}
+
+const (
+	trueSkillMu              = 25.0
+	trueSkillSigma           = trueSkillMu / 3
+	trueSkillBeta            = trueSkillSigma / 2
+	trueSkillTau             = trueSkillSigma / 100
+	trueSkillDrawProbability = 0.1
+
+	// trueSkillDisplayScale scales the conservative skill estimate (mu - 3*sigma) for display
+	trueSkillDisplayScale = 40
+)
+
+// System implements Rater interface
+func (TrueSkillRater) System() string {
+	return "trueskill"
+}
+
+// Initial implements Rater interface
+func (TrueSkillRater) Initial() Rating {
+	return Rating{
+		Rating:    trueSkillDisplay(trueSkillMu, trueSkillSigma),
+		Mu:        trueSkillMu,
+		Deviation: trueSkillSigma,
+	}
+}
+
+// Rate implements Rater interface
+func (tr TrueSkillRater) Rate(ratings []Rating, places []int) []Rating {
+	drawProbability := tr.DrawProbability
+	if drawProbability == 0 {
+		drawProbability = trueSkillDrawProbability
+	}
+	epsilon := trueSkillDrawMargin(drawProbability)
+
+	// apply dynamics factor to each player prior to the update
+	mus := make([]float64, len(ratings))
+	variances := make([]float64, len(ratings))
+	for i, r := range ratings {
+		sigma := r.Deviation
+		if sigma == 0 {
+			mus[i], sigma = trueSkillMu, trueSkillSigma
+		} else {
+			mus[i] = r.Mu
+		}
+		variances[i] = sigma*sigma + trueSkillTau*trueSkillTau
+	}
+
+	order := make([]int, len(ratings))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int { return places[i] - places[j] })
+
+	muDeltas := make([]float64, len(ratings))
+	varianceFactors := make([]float64, len(ratings))
+	for i := range varianceFactors {
+		varianceFactors[i] = 1
+	}
+
+	for k := 0; k+1 < len(order); k++ {
+		w, l := order[k], order[k+1]
+		c := math.Sqrt(2*trueSkillBeta*trueSkillBeta + variances[w] + variances[l])
+		t := (mus[w] - mus[l]) / c
+		e := epsilon / c
+
+		var v, vw float64
+		if places[w] == places[l] {
+			v, vw = trueSkillVDraw(t, e), trueSkillWDraw(t, e)
+		} else {
+			v, vw = trueSkillVWin(t, e), trueSkillWWin(t, e)
+		}
+
+		muDeltas[w] += variances[w] / c * v
+		muDeltas[l] -= variances[l] / c * v
+		varianceFactors[w] *= 1 - variances[w]/(c*c)*vw
+		varianceFactors[l] *= 1 - variances[l]/(c*c)*vw
+	}
+
+	rated := make([]Rating, len(ratings))
+	for i, r := range ratings {
+		mu := mus[i] + muDeltas[i]
+		sigma := math.Sqrt(variances[i] * math.Max(varianceFactors[i], 0.0001))
+		r.Mu, r.Deviation = mu, sigma
+		r.Rating = trueSkillDisplay(mu, sigma)
+		rated[i] = r
+	}
+	return rated
+}
+
+func trueSkillDisplay(mu, sigma float64) int {
+	return int(math.Round(trueSkillDisplayScale * (mu - 3*sigma)))
+}
+
+// trueSkillDrawMargin returns the draw margin associated with the draw probability for two players
+func trueSkillDrawMargin(p float64) float64 {
+	return normInvCDF((p+1)/2) * math.Sqrt2 * trueSkillBeta
+}
+
+func trueSkillVWin(t, e float64) float64 {
+	denom := normCDF(t - e)
+	if denom < 2.222758749e-162 {
+		return -t + e
+	}
+	return normPDF(t-e) / denom
+}
+
+func trueSkillWWin(t, e float64) float64 {
+	denom := normCDF(t - e)
+	if denom < 2.222758749e-162 {
+		if t < 0 {
+			return 1
+		}
+		return 0
+	}
+	v := trueSkillVWin(t, e)
+	return v * (v + t - e)
+}
+
+func trueSkillVDraw(t, e float64) float64 {
+	abs := math.Abs(t)
+	denom := normCDF(e-abs) - normCDF(-e-abs)
+	if denom < 2.222758749e-162 {
+		if t < 0 {
+			return -t - e
+		}
+		return -t + e
+	}
+	v := (normPDF(-e-abs) - normPDF(e-abs)) / denom
+	if t < 0 {
+		return -v
+	}
+	return v
+}
+
+func trueSkillWDraw(t, e float64) float64 {
+	abs := math.Abs(t)
+	denom := normCDF(e-abs) - normCDF(-e-abs)
+	if denom < 2.222758749e-162 {
+		return 1
+	}
+	v := trueSkillVDraw(abs, e)
+	return v*v + ((e-abs)*normPDF(e-abs)+(e+abs)*normPDF(e+abs))/denom
+}
+
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normInvCDF(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
@@ -32,6 +32,16 @@ type userData struct {
 	UpdatedAt          time.Time
 }
 
+// cuErr returns the error of a current user request.
+// In compat mode, the error is returned with a nil CU, as expected by existing frontends.
+func cuErr(ctx *gin.Context, err error) {
+	if structuredErrors(ctx) {
+		JErr(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"CU": nil, "Error": err.Error()})
+}
+
 func (cl *Client) cuHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		Debugf(ctx, msgEnter)
@@ -39,7 +49,7 @@ func (cl *Client) cuHandler() gin.HandlerFunc {
 
 		cu, err := cl.RequireLogin(ctx)
 		if err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"CU": nil, "Error": err.Error()})
+			cuErr(ctx, err)
 			return
 		}
 
@@ -54,7 +64,7 @@ func (cl *GameClient[GT, G]) fbCUHandler() gin.HandlerFunc {
 
 		cu, err := cl.RequireLogin(ctx)
 		if err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"CU": nil, "Error": err.Error()})
+			cuErr(ctx, err)
 			return
 		}
 
@@ -81,12 +91,12 @@ func (cl *GameClient[GT, G]) updateGodModeHandler() gin.HandlerFunc {
 
 		token := cl.GetSessionToken(ctx)
 		if token == nil {
-			ctx.JSON(http.StatusOK, gin.H{"Error": ErrNotLoggedIn})
+			JErr(ctx, ErrNotLoggedIn)
 			return
 		}
 
 		if !token.Data.Admin {
-			ctx.JSON(http.StatusOK, gin.H{"Error": ErrNotAdmin})
+			JErr(ctx, ErrNotAdmin)
 			return
 		}
 
@@ -95,7 +105,7 @@ func (cl *GameClient[GT, G]) updateGodModeHandler() gin.HandlerFunc {
 		})
 
 		if err := ctx.ShouldBind(obj); err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"Error": err})
+			JErr(ctx, err)
 			return
 		}
 
@@ -103,7 +113,7 @@ func (cl *GameClient[GT, G]) updateGodModeHandler() gin.HandlerFunc {
 		cl.setSessionToken(ctx, token)
 
 		if err := cl.SaveSession(ctx); err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"Error": err})
+			JErr(ctx, err)
 			return
 		}
 		ctx.JSON(http.StatusOK, gin.H{"CU": token.ToUser()})
@@ -0,0 +1,48 @@
+package sn
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCUHandlerNotLoggedIn(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.GET("/user/current", cl.cuHandler())
+
+	// compat errors retain the body expected by existing frontends
+	w := serve(cl, http.MethodGet, "/user/current", 0, "")
+	var compat map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &compat); err != nil {
+		t.Fatal(err)
+	}
+
+	if cu, found := compat["CU"]; !found || cu != nil || compat["Error"] != ErrNotLoggedIn.Error() || w.Code != http.StatusOK {
+		t.Errorf("compat: got %d %s, want 200 with nil CU and Error", w.Code, w.Body)
+	}
+
+	w = serve(cl, http.MethodGet, "/user/current", 0, "", ErrorFormatHeader, "structured")
+	var structured struct{ Error ErrorBody }
+	if err := json.Unmarshal(w.Body.Bytes(), &structured); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusForbidden || structured.Error.Code != ForbiddenCode {
+		t.Errorf("structured: got %d %s, want %d with code %q", w.Code, w.Body, http.StatusForbidden, ForbiddenCode)
+	}
+}
+
+func TestCUHandlerLoggedIn(t *testing.T) {
+	cl := newTestClient(t)
+	cl.Router.GET("/user/current", cl.cuHandler())
+
+	w := serve(cl, http.MethodGet, "/user/current", 3, "")
+	var resp struct{ CU *User }
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.CU == nil || resp.CU.ID != 3 {
+		t.Errorf("got %s, want CU having ID 3", w.Body)
+	}
+}
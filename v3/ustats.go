@@ -1,11 +1,9 @@
 package sn
 
 import (
-	"fmt"
+	"errors"
 	"time"
 
-	"cloud.google.com/go/firestore"
-	"github.com/elliotchance/pie/v2"
 	"github.com/gin-gonic/gin"
 )
 
@@ -51,10 +49,6 @@ func newUStat(uid UID) ustat {
 	return ustat{ID: uid}
 }
 
-func (cl *GameClient[GT, G]) ustatDocRef(uid UID) *firestore.DocumentRef {
-	return cl.FS.Collection("UStat").Doc(fmt.Sprintf("%d", uid))
-}
-
 func (g *Game[S, T, P]) updateUStats(stats []ustat, pstats []*Stats, uids []UID) []ustat {
 	ustats := make([]ustat, len(stats))
 	for i := range stats {
@@ -93,33 +87,26 @@ func (cl *GameClient[GT, G]) getUStats(ctx *gin.Context, uids ...UID) ([]ustat,
 	Debugf(ctx, msgEnter)
 	defer Debugf(ctx, msgExit)
 
-	refs := pie.Map(uids, func(uid UID) *firestore.DocumentRef { return cl.ustatDocRef(uid) })
-	snaps, err := cl.FS.GetAll(ctx, refs)
-	if err != nil {
-		return nil, err
-	}
-
-	ustats := make([]ustat, len(snaps))
-	for i, snap := range snaps {
-		if !snap.Exists() {
-			ustats[i] = newUStat(uids[i])
-			continue
-		}
-
+	ustats := make([]ustat, len(uids))
+	for i, uid := range uids {
 		var ustat ustat
-		if err := snap.DataTo(&ustat); err != nil {
+		switch err := cl.store.GetUStat(ctx, uid, &ustat); {
+		case errors.Is(err, ErrNotFound):
+			ustats[i] = newUStat(uid)
+		case err != nil:
 			return nil, err
+		default:
+			ustats[i] = ustat
 		}
-		ustats[i] = ustat
 	}
 	return ustats, nil
 }
 
-func (cl *GameClient[GT, G]) txSaveUStats(tx *firestore.Transaction, ustats []ustat) error {
+func (cl *GameClient[GT, G]) txSaveUStats(tx Tx, ustats []ustat) error {
 	t := time.Now()
 	for _, ustat := range ustats {
 		ustat.UpdatedAt = t
-		if err := tx.Set(cl.ustatDocRef(ustat.ID), ustat); err != nil {
+		if err := tx.SetUStat(ustat.ID, ustat); err != nil {
 			return err
 		}
 	}